@@ -1,89 +1,193 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
-	"math/rand"
+	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/larriantoniy/tg_pipe_bot/internal/adapters/httpcache"
+	"github.com/larriantoniy/tg_pipe_bot/internal/adapters/httpserver"
+	"github.com/larriantoniy/tg_pipe_bot/internal/adapters/metrics"
+	"github.com/larriantoniy/tg_pipe_bot/internal/adapters/proxy"
+	"github.com/larriantoniy/tg_pipe_bot/internal/adapters/sqlite"
 	"github.com/larriantoniy/tg_pipe_bot/internal/adapters/tdlib"
 	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/larriantoniy/tg_pipe_bot/internal/dedup"
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/parse"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
 	prediction "github.com/larriantoniy/tg_pipe_bot/internal/useCases"
 )
 
 const (
 	envDev  = "dev"
 	envProd = "prod"
+
+	// closeTimeout — отдельный срок на закрытие TDLib и HTTP-сервера после дренажа конвейера
+	closeTimeout = 10 * time.Second
 )
 
 func main() {
 
-	cfg, err := config.Load()
+	flags := config.ParseFlags()
+	cfg, err := config.Load(flags.ConfigPath)
+	if flags.PrintConfig && cfg != nil {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "ошибка вывода конфига: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	if err != nil {
-		// либо log.Fatalf, либо panic с читаемым сообщением
-		fmt.Fprintf(os.Stderr, "ошибка загрузки конфига: %v\n", err)
+		fmt.Fprintf(os.Stderr, "ошибка загрузки конфига:\n%v\n", err)
 		os.Exit(1)
 	}
+	if flags.PrintConfig {
+		return
+	}
 	logger := setupLogger(cfg.Env)
-	ps := prediction.NewPredictionService(logger)
 
-	tdClient, err := tdlib.NewClient(logger, cfg)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	m := metrics.New()
+	parsers := parse.NewDefaultRegistry(parse.NewKickoffParser(cfg.Time.SourceLocation()))
+	if err := parsers.SetDisabled(cfg.Parsers.Disabled); err != nil {
+		logger.Error("Parsers init failed", "error", err)
+		os.Exit(1)
+	}
+	scraperProxies, err := proxy.New(logger, cfg.Proxy.Scraper, cfg.Scraper.BaseURL)
+	if err != nil {
+		logger.Error("Scraper proxy init failed", "error", err)
+		os.Exit(1)
+	}
+	scraperProxies.Start(ctx)
+	var scraperTransport http.RoundTripper = scraperProxies
+	if !cfg.Scraper.Cache.Disabled {
+		scraperTransport = httpcache.New(logger, cfg.Scraper.Cache, scraperProxies, m)
+	}
+	textParser := tdlib.NewTextParser()
+	templates, err := prediction.NewTemplates(cfg.Templates, cfg.Scraper.BaseURL, textParser)
+	if err != nil {
+		logger.Error("Templates init failed", "error", err)
+		os.Exit(1)
+	}
+	ps := prediction.NewPredictionService(logger, cfg, parsers, m, scraperTransport, templates)
+
+	// Сервер поднимается до авторизации: /healthz отвечает и во время неё,
+	// а /auth принимает код и пароль
+	auth := tdlib.NewAuthorizer(ctx, logger, cfg.Telegram.Auth)
+	health := httpserver.NewHealth(logger, cfg.HTTP.Health, ps)
+	health.SetTelegram(auth)
+	httpSrv := httpserver.New(logger, cfg.HTTP)
+	httpSrv.Handle("/metrics", m.Handler())
+	httpSrv.Handle("/healthz", http.HandlerFunc(health.Live))
+	httpSrv.Handle("/readyz", http.HandlerFunc(health.Ready))
+	httpSrv.Handle("/auth", httpserver.NewAuthHandler(logger, cfg.Telegram.Auth.AdminToken, auth))
+	if err := httpSrv.Start(); err != nil {
+		logger.Error("HTTP server init failed", "error", err)
+		os.Exit(1)
+	}
+
+	tdClient, err := tdlib.NewClient(logger, cfg, auth)
 	if err != nil {
 		logger.Error("TDLib init failed", "error", err)
 		os.Exit(1)
 	}
-	adminChans, err := tdClient.GetAdminChannelsSimple()
+	health.SetTelegram(tdClient)
+
+	store, err := sqlite.New(logger, cfg.Storage.Path)
 	if err != nil {
-		logger.Error("TDLib get admin channels failed", "error", err)
+		logger.Error("Storage init failed", "path", cfg.Storage.Path, "error", err)
+		os.Exit(1)
 	}
-	for {
-		updates, err := tdClient.Listen()
+
+	var dedupPersist ports.DedupPersister
+	if cfg.Dedup.Persist {
+		dedupPersist = store
+	}
+	dedupStore := dedup.New(logger, cfg.Dedup.TTL, dedupPersist)
+	m.RegisterDedup(dedupStore.Suppressed)
+
+	pipeline := prediction.NewPipeline(logger, cfg, prediction.PipelineDeps{
+		Service:  ps,
+		Store:    store,
+		Pending:  store,
+		Dedup:    dedupStore,
+		Sender:   tdClient,
+		Metrics:  m,
+		Files:    tdClient,
+		Channels: tdClient,
+	})
+	// Конвейер живёт дольше ctx: после сигнала он ещё дообрабатывает очередь
+	pipeline.Start(context.Background())
+
+	// Маршруты, паузы, форматы и шаблоны меняются без перезапуска и переавторизации TDLib
+	watcher := config.NewWatcher(logger, flags.ConfigPath, cfg, func(next *config.Config) error {
+		templates, err := prediction.NewTemplates(next.Templates, next.Scraper.BaseURL, textParser)
+		if err != nil {
+			return err
+		}
+		if err := parsers.SetDisabled(next.Parsers.Disabled); err != nil {
+			return err
+		}
+		ps.SetTemplates(templates)
+		pipeline.Reload(next)
+		return nil
+	})
+	go watcher.Run(ctx)
+
+	for ctx.Err() == nil {
+		updates, err := tdClient.Listen(ctx)
 		if err != nil {
 			logger.Error("Listen failed, retrying", "error", err)
-			time.Sleep(time.Second) // можно увеличить backoff по желанию
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second): // можно увеличить backoff по желанию
+			}
 			continue
 		}
 
 		for msg := range updates {
-			rand.Seed(time.Now().UnixNano())
-			dur := randDuration(10, 50)
-			time.Sleep(dur)
-			logger.Info("New message", "chat_id", msg.ChatID, "text", msg.Text, "duration", dur)
-			capper, formatted, err := ps.GetFormatedPrediction(msg, cfg.BasePredictUrl)
-			if err != nil {
-				logger.Error("GetFormattedPrediction", "chat_id", msg.ChatID, "text", msg.Text, "error", err)
-				continue
-			}
-
-			chatIdStr, ok := adminChans[capper]
-			if !ok || chatIdStr == "" {
-				logger.Error("No target channel for capper", "capper", capper)
-				continue
-			}
-			chatId, err := strconv.ParseInt(chatIdStr, 10, 64)
-			if err != nil {
-				logger.Error("Invalid chat ID for capper", "chat_id str", chatIdStr, "err", err)
-				continue
+			logger.Info("New message", "chat_id", msg.ChatID, "text", msg.Text)
+			// Правки и удаления приходят тем же каналом, но новыми сообщениями не считаются
+			if msg.Event == domain.MessageNew {
+				m.MessageReceived(msg.ChatID)
 			}
-			err = tdClient.SendMessage(chatId, formatted)
-			if err != nil {
-				logger.Error("SendMessage failed", "chat_name", msg.ChatName, "text", msg.Text, "error", err)
-				continue
+			if err := pipeline.Process(msg); err != nil {
+				logger.Error("Process message failed", "chat_id", msg.ChatID, "chat_name", msg.ChatName, "error", err)
 			}
 		}
-		logger.Warn("Listen exited — вероятно упало соединение, пробуем снова...")
+		if ctx.Err() == nil {
+			logger.Warn("Listen exited — вероятно упало соединение, пробуем снова...")
+		}
 	}
-}
-func randDuration(minSec, maxSec int) time.Duration {
-	if maxSec <= minSec {
-		return time.Duration(minSec) * time.Second
-	}
-	sec := rand.Intn(maxSec-minSec+1) + minSec
-	// добавим миллисекундный джиттер 0..999ms чтобы интервалы выглядили менее ровными
-	ms := rand.Intn(1000)
-	return time.Duration(sec)*time.Second + time.Duration(ms)*time.Millisecond
+
+	logger.Info("Shutdown signal received, draining", "timeout", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := pipeline.Stop(shutdownCtx); err != nil {
+		logger.Error("Pipeline stop failed", "error", err)
+	}
+	// Дренаж может израсходовать весь shutdown_timeout, а TDLib нужно дождаться
+	// состояния Closed, иначе база не будет сброшена на диск
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancelClose()
+	if err := tdClient.Close(closeCtx); err != nil {
+		logger.Error("TDLib close failed", "error", err)
+	}
+	if err := httpSrv.Shutdown(closeCtx); err != nil {
+		logger.Error("HTTP server shutdown failed", "error", err)
+	}
+	if err := store.Close(); err != nil {
+		logger.Error("Storage close failed", "error", err)
+	}
+	logger.Info("Shutdown complete")
 }
 
 func setupLogger(env string) *slog.Logger {
@@ -0,0 +1,169 @@
+package httpcache
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+	"golang.org/x/sync/singleflight"
+)
+
+// Результаты обращения к кэшу для метрик
+const (
+	resultHit         = "hit"
+	resultMiss        = "miss"
+	resultRevalidated = "revalidated"
+	resultCoalesced   = "coalesced"
+)
+
+// Cache — http.RoundTripper с коротким кэшем GET-ответов по URL.
+// Несколько анонсов одного каппера за секунды дают один запрос к сайту:
+// свежая страница отдаётся из памяти, устаревшая перепроверяется условным
+// запросом, а одновременные запросы одной страницы ждут первый.
+// Кэшируются только ответы 200; остальные методы идут мимо кэша.
+type Cache struct {
+	logger  *slog.Logger
+	cfg     config.ScraperCacheConfig
+	next    http.RoundTripper
+	metrics ports.Metrics
+	group   singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+var _ http.RoundTripper = (*Cache)(nil)
+
+// entry — сохранённый ответ; после создания не меняется
+type entry struct {
+	status  int
+	header  http.Header
+	body    []byte
+	fetched time.Time
+}
+
+// New оборачивает next кэшем; next == nil — http.DefaultTransport
+func New(logger *slog.Logger, cfg config.ScraperCacheConfig, next http.RoundTripper, metrics ports.Metrics) *Cache {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Cache{
+		logger:  logger,
+		cfg:     cfg,
+		next:    next,
+		metrics: metrics,
+		entries: make(map[string]*entry),
+	}
+}
+
+func (c *Cache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return c.next.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	if e := c.get(key); e != nil && time.Since(e.fetched) < c.cfg.TTL {
+		c.metrics.CacheLookup(resultHit)
+		return e.response(req), nil
+	}
+
+	var leader bool
+	v, err, _ := c.group.Do(key, func() (any, error) {
+		leader = true
+		return c.fetch(req, key)
+	})
+	if !leader {
+		c.metrics.CacheLookup(resultCoalesced)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v.(*entry).response(req), nil
+}
+
+// fetch запрашивает страницу, а если она уже есть в кэше — только изменения
+func (c *Cache) fetch(req *http.Request, key string) (*entry, error) {
+	stale := c.get(key)
+
+	out := req.Clone(req.Context())
+	if stale != nil {
+		if etag := stale.header.Get("ETag"); etag != "" {
+			out.Header.Set("If-None-Match", etag)
+		}
+		if lm := stale.header.Get("Last-Modified"); lm != "" {
+			out.Header.Set("If-Modified-Since", lm)
+		}
+	}
+
+	resp, err := c.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && stale != nil {
+		io.Copy(io.Discard, resp.Body)
+		e := &entry{status: stale.status, header: stale.header, body: stale.body, fetched: time.Now()}
+		c.put(key, e)
+		c.metrics.CacheLookup(resultRevalidated)
+		return e, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", key, err)
+	}
+	e := &entry{status: resp.StatusCode, header: resp.Header.Clone(), body: body, fetched: time.Now()}
+	c.metrics.CacheLookup(resultMiss)
+	if resp.StatusCode == http.StatusOK {
+		c.put(key, e)
+	}
+	return e, nil
+}
+
+func (c *Cache) get(key string) *entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[key]
+}
+
+// put сохраняет ответ; при переполнении вытесняет самую давно загруженную страницу
+func (c *Cache) put(key string, e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.cfg.MaxEntries {
+		var (
+			oldestKey string
+			oldest    time.Time
+		)
+		for k, v := range c.entries {
+			if oldestKey == "" || v.fetched.Before(oldest) {
+				oldestKey, oldest = k, v.fetched
+			}
+		}
+		delete(c.entries, oldestKey)
+		c.logger.Debug("Scraper cache entry evicted", "url", oldestKey)
+	}
+	c.entries[key] = e
+}
+
+// response — новая копия ответа для каждого вызывающего
+func (e *entry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.status, http.StatusText(e.status)),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,224 @@
+package httpcache
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+)
+
+// lookups запоминает результаты обращений к кэшу
+type lookups struct {
+	mu      sync.Mutex
+	results map[string]int
+}
+
+func (l *lookups) MessageReceived(int64)                {}
+func (l *lookups) ParseFailed(string, string)           {}
+func (l *lookups) ScrapeObserved(string, time.Duration) {}
+func (l *lookups) MessageSent(int64)                    {}
+func (l *lookups) SendFailed(int64)                     {}
+
+func (l *lookups) CacheLookup(result string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.results == nil {
+		l.results = make(map[string]int)
+	}
+	l.results[result]++
+}
+
+func (l *lookups) count(result string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.results[result]
+}
+
+// origin — сайт за кэшем: считает запросы по пути, отвечает через handle
+type origin struct {
+	mu       sync.Mutex
+	requests map[string]int
+	handle   func(req *http.Request) *http.Response
+}
+
+func (o *origin) RoundTrip(req *http.Request) (*http.Response, error) {
+	o.mu.Lock()
+	if o.requests == nil {
+		o.requests = make(map[string]int)
+	}
+	o.requests[req.URL.Path]++
+	o.mu.Unlock()
+	return o.handle(req), nil
+}
+
+func (o *origin) count(path string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.requests[path]
+}
+
+func reply(req *http.Request, status int, body string, header http.Header) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func page(req *http.Request) *http.Response {
+	return reply(req, http.StatusOK, "page "+req.URL.Path, nil)
+}
+
+func newCache(cfg config.ScraperCacheConfig, next http.RoundTripper) (*Cache, *lookups) {
+	m := &lookups{}
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg, next, m), m
+}
+
+func get(t *testing.T, c *Cache, method, url string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestCacheHitAndMiss(t *testing.T) {
+	o := &origin{handle: page}
+	c, m := newCache(config.ScraperCacheConfig{TTL: time.Minute, MaxEntries: 10}, o)
+
+	for i := 0; i < 3; i++ {
+		if _, body := get(t, c, http.MethodGet, "http://site/a"); body != "page /a" {
+			t.Fatalf("body = %q", body)
+		}
+	}
+	if n := o.count("/a"); n != 1 {
+		t.Errorf("origin requests = %d, want 1", n)
+	}
+	if m.count(resultMiss) != 1 || m.count(resultHit) != 2 {
+		t.Errorf("lookups = %v, want 1 miss and 2 hits", m.results)
+	}
+}
+
+func TestCacheRevalidates(t *testing.T) {
+	o := &origin{}
+	o.handle = func(req *http.Request) *http.Response {
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			return reply(req, http.StatusNotModified, "", nil)
+		}
+		return reply(req, http.StatusOK, "v1 body", http.Header{"Etag": {`"v1"`}})
+	}
+	// Нулевой TTL: каждая повторная загрузка перепроверяется
+	c, m := newCache(config.ScraperCacheConfig{TTL: 0, MaxEntries: 10}, o)
+
+	for i := 0; i < 2; i++ {
+		status, body := get(t, c, http.MethodGet, "http://site/a")
+		if status != http.StatusOK || body != "v1 body" {
+			t.Fatalf("got %d %q, want 200 %q", status, body, "v1 body")
+		}
+	}
+	if m.count(resultMiss) != 1 || m.count(resultRevalidated) != 1 {
+		t.Errorf("lookups = %v, want 1 miss and 1 revalidated", m.results)
+	}
+}
+
+func TestCacheCoalescesConcurrentRequests(t *testing.T) {
+	release := make(chan struct{})
+	o := &origin{handle: func(req *http.Request) *http.Response {
+		<-release
+		return page(req)
+	}}
+	c, m := newCache(config.ScraperCacheConfig{TTL: time.Minute, MaxEntries: 10}, o)
+
+	const callers = 5
+	bodies := make(chan string, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, body := get(t, c, http.MethodGet, "http://site/a")
+			bodies <- body
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(bodies)
+
+	for body := range bodies {
+		if body != "page /a" {
+			t.Errorf("body = %q", body)
+		}
+	}
+	if n := o.count("/a"); n != 1 {
+		t.Errorf("origin requests = %d, want 1", n)
+	}
+	// Опоздавший к общему запросу получает уже сохранённую страницу
+	if got := m.count(resultCoalesced) + m.count(resultHit); got != callers-1 {
+		t.Errorf("lookups = %v, want %d coalesced or hit", m.results, callers-1)
+	}
+}
+
+func TestCacheBypassesNonGet(t *testing.T) {
+	o := &origin{handle: page}
+	c, m := newCache(config.ScraperCacheConfig{TTL: time.Minute, MaxEntries: 10}, o)
+
+	get(t, c, http.MethodHead, "http://site/a")
+	get(t, c, http.MethodHead, "http://site/a")
+	if n := o.count("/a"); n != 2 {
+		t.Errorf("origin requests = %d, want 2", n)
+	}
+	if len(m.results) != 0 {
+		t.Errorf("lookups = %v, want none", m.results)
+	}
+}
+
+func TestCacheSkipsNonOK(t *testing.T) {
+	o := &origin{handle: func(req *http.Request) *http.Response {
+		return reply(req, http.StatusServiceUnavailable, "down", nil)
+	}}
+	c, _ := newCache(config.ScraperCacheConfig{TTL: time.Minute, MaxEntries: 10}, o)
+
+	for i := 0; i < 2; i++ {
+		if status, _ := get(t, c, http.MethodGet, "http://site/a"); status != http.StatusServiceUnavailable {
+			t.Fatalf("status = %d, want 503", status)
+		}
+	}
+	if n := o.count("/a"); n != 2 {
+		t.Errorf("origin requests = %d, want 2", n)
+	}
+}
+
+func TestCacheEvictsOldest(t *testing.T) {
+	o := &origin{handle: page}
+	c, _ := newCache(config.ScraperCacheConfig{TTL: time.Minute, MaxEntries: 2}, o)
+
+	for _, path := range []string{"/a", "/b", "/c", "/b", "/c", "/a"} {
+		get(t, c, http.MethodGet, "http://site"+path)
+	}
+	want := map[string]int{"/a": 2, "/b": 1, "/c": 1}
+	for path, n := range want {
+		if got := o.count(path); got != n {
+			t.Errorf("origin requests for %s = %d, want %d", path, got, n)
+		}
+	}
+}
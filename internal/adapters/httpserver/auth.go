@@ -0,0 +1,88 @@
+package httpserver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+)
+
+// AuthHandler — /auth: GET показывает шаг авторизации и QR-ссылку,
+// POST принимает phone, code или password (форма или JSON).
+// Доступ только с заголовком «Authorization: Bearer <token>».
+type AuthHandler struct {
+	logger *slog.Logger
+	token  string
+	auth   ports.Authenticator
+}
+
+func NewAuthHandler(logger *slog.Logger, token string, auth ports.Authenticator) *AuthHandler {
+	return &AuthHandler{
+		logger: logger,
+		token:  token,
+		auth:   auth,
+	}
+}
+
+func (h *AuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.token == "" {
+		http.Error(w, "auth endpoint disabled: TG_AUTH_ADMIN_TOKEN is not set", http.StatusForbidden)
+		return
+	}
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(given), []byte(h.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := h.submit(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.auth.AuthStatus()); err != nil {
+		h.logger.Error("Write auth status failed", "error", err)
+	}
+}
+
+func (h *AuthHandler) submit(r *http.Request) error {
+	values := make(map[domain.AuthField]string)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body map[string]string
+		if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 4096)).Decode(&body); err != nil {
+			return err
+		}
+		for k, v := range body {
+			values[domain.AuthField(k)] = v
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		for k := range r.PostForm {
+			values[domain.AuthField(k)] = r.PostForm.Get(k)
+		}
+	}
+
+	for field, value := range values {
+		if err := h.auth.SubmitAuth(field, value); err != nil {
+			return err
+		}
+		// Сами значения не логируем
+		h.logger.Info("TDLib authorization input submitted via HTTP", "field", field)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+	"github.com/zelenin/go-tdlib/client"
+)
+
+// Health отвечает на /healthz (процесс жив и слушает Telegram) и
+// /readyz (авторизован, слушает обновления, сайт капперов доступен).
+type Health struct {
+	logger *slog.Logger
+	cfg    config.HealthConfig
+	site   ports.SiteChecker
+
+	// telegram появляется после создания клиента, до этого бот «стартует»
+	telegram atomic.Pointer[ports.TelegramStatusProvider]
+
+	siteMu      sync.Mutex
+	siteErr     error
+	siteChecked time.Time
+}
+
+func NewHealth(logger *slog.Logger, cfg config.HealthConfig, site ports.SiteChecker) *Health {
+	return &Health{
+		logger: logger,
+		cfg:    cfg,
+		site:   site,
+	}
+}
+
+// SetTelegram подключает клиент Telegram к проверкам
+func (h *Health) SetTelegram(tg ports.TelegramStatusProvider) {
+	h.telegram.Store(&tg)
+}
+
+type check struct {
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type report struct {
+	Status      string           `json:"status"`
+	AuthState   string           `json:"auth_state,omitempty"`
+	LastUpdate  *time.Time       `json:"last_update,omitempty"`
+	LastMessage *time.Time       `json:"last_message,omitempty"`
+	Checks      map[string]check `json:"checks"`
+}
+
+func (r *report) add(name string, ok bool, detail string) {
+	r.Checks[name] = check{OK: ok, Detail: detail}
+	if !ok {
+		r.Status = "fail"
+	}
+}
+
+// Live — /healthz: падает, если сессия TDLib закрыта, слушатель обновлений
+// умер или обновлений нет дольше max_silence
+func (h *Health) Live(w http.ResponseWriter, r *http.Request) {
+	rep := h.telegramReport(false)
+	h.write(w, rep)
+}
+
+// Ready — /readyz: готов принимать и публиковать анонсы
+func (h *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	rep := h.telegramReport(true)
+	err := h.checkSite(r.Context())
+	rep.add("capper_site", err == nil, errText(err))
+	h.write(w, rep)
+}
+
+func (h *Health) telegramReport(ready bool) *report {
+	rep := &report{Status: "ok", Checks: make(map[string]check)}
+	p := h.telegram.Load()
+	if p == nil {
+		// Клиент ещё создаётся (авторизация): жив, но не готов
+		rep.add("tdlib", !ready, "starting")
+		return rep
+	}
+	st := (*p).Status()
+	rep.AuthState = st.AuthState
+	if !st.LastUpdate.IsZero() {
+		rep.LastUpdate = &st.LastUpdate
+	}
+	if !st.LastMessage.IsZero() {
+		rep.LastMessage = &st.LastMessage
+	}
+
+	switch st.AuthState {
+	case client.TypeAuthorizationStateClosing, client.TypeAuthorizationStateClosed, client.TypeAuthorizationStateLoggingOut:
+		rep.add("tdlib_auth", false, st.AuthState)
+	default:
+		rep.add("tdlib_auth", st.Authorized || !ready, st.AuthState)
+	}
+	if st.Authorized {
+		rep.add("listener", st.Listening, "")
+	}
+	if h.cfg.MaxSilence > 0 && !st.LastUpdate.IsZero() {
+		silence := time.Since(st.LastUpdate)
+		rep.add("updates", silence < h.cfg.MaxSilence, "last update "+silence.Round(time.Second).String()+" ago")
+	}
+	return rep
+}
+
+// checkSite проверяет сайт не чаще site_check_interval, чтобы частые пробы не нагружали его
+func (h *Health) checkSite(ctx context.Context) error {
+	h.siteMu.Lock()
+	defer h.siteMu.Unlock()
+	if !h.siteChecked.IsZero() && time.Since(h.siteChecked) < h.cfg.SiteCheckInterval {
+		return h.siteErr
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.SiteTimeout)
+	defer cancel()
+	h.siteErr = h.site.CheckSite(ctx)
+	h.siteChecked = time.Now()
+	if h.siteErr != nil {
+		h.logger.Warn("Capper site check failed", "error", h.siteErr)
+	}
+	return h.siteErr
+}
+
+func (h *Health) write(w http.ResponseWriter, rep *report) {
+	w.Header().Set("Content-Type", "application/json")
+	if rep.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(rep); err != nil {
+		h.logger.Error("Write health report failed", "error", err)
+	}
+}
+
+func errText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,57 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+)
+
+// Server — служебный HTTP-сервер бота (метрики и т.п.)
+type Server struct {
+	logger *slog.Logger
+	mux    *http.ServeMux
+	srv    *http.Server
+}
+
+func New(logger *slog.Logger, cfg config.HTTPConfig) *Server {
+	mux := http.NewServeMux()
+	return &Server{
+		logger: logger,
+		mux:    mux,
+		srv: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}
+}
+
+// Handle регистрирует обработчик; вызывать до Start
+func (s *Server) Handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+// Start занимает порт и обслуживает запросы в фоне
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return fmt.Errorf("http listen %s: %w", s.srv.Addr, err)
+	}
+	s.logger.Info("HTTP server started", "addr", ln.Addr().String())
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("HTTP server failed", "error", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pipebot"
+
+var _ ports.Metrics = (*Metrics)(nil)
+
+// Metrics — реализация ports.Metrics на Prometheus со своим реестром
+type Metrics struct {
+	registry *prometheus.Registry
+
+	received    *prometheus.CounterVec
+	parseFailed *prometheus.CounterVec
+	scrape      *prometheus.HistogramVec
+	cache       *prometheus.CounterVec
+	sent        *prometheus.CounterVec
+	sendFailed  *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		received: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_received_total",
+			Help:      "Messages received from source chats.",
+		}, []string{"chat_id"}),
+		parseFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "parse_failures_total",
+			Help:      "Messages that could not be parsed as forecasts.",
+		}, []string{"parser", "reason"}),
+		scrape: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "scrape_duration_seconds",
+			Help:      "Capper page request latency by HTTP status.",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 5, 10},
+		}, []string{"status"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "scrape_cache_requests_total",
+			Help:      "Capper page requests by cache result.",
+		}, []string{"result"}),
+		sent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_sent_total",
+			Help:      "Forecasts sent to target channels.",
+		}, []string{"chat_id"}),
+		sendFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "send_failures_total",
+			Help:      "Failed sends to target channels.",
+		}, []string{"chat_id"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.received, m.parseFailed, m.scrape, m.cache, m.sent, m.sendFailed,
+	)
+	return m
+}
+
+// Handler отдаёт метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDedup публикует число отсечённых дублей
+func (m *Metrics) RegisterDedup(suppressed func() int64) {
+	m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dedup_suppressed_total",
+		Help:      "Duplicate announcements suppressed since start.",
+	}, func() float64 { return float64(suppressed()) }))
+}
+
+func (m *Metrics) MessageReceived(chatID int64) {
+	m.received.WithLabelValues(chatLabel(chatID)).Inc()
+}
+
+func (m *Metrics) ParseFailed(parser, reason string) {
+	m.parseFailed.WithLabelValues(parser, reason).Inc()
+}
+
+func (m *Metrics) ScrapeObserved(status string, d time.Duration) {
+	m.scrape.WithLabelValues(status).Observe(d.Seconds())
+}
+
+func (m *Metrics) CacheLookup(result string) {
+	m.cache.WithLabelValues(result).Inc()
+}
+
+func (m *Metrics) MessageSent(chatID int64) {
+	m.sent.WithLabelValues(chatLabel(chatID)).Inc()
+}
+
+func (m *Metrics) SendFailed(chatID int64) {
+	m.sendFailed.WithLabelValues(chatLabel(chatID)).Inc()
+}
+
+func chatLabel(chatID int64) string {
+	return strconv.FormatInt(chatID, 10)
+}
@@ -0,0 +1,189 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+)
+
+// direct — индекс «без прокси»
+const direct = -1
+
+// checkTimeout — сколько ждать ответа сайта при проверке прокси
+const checkTimeout = 10 * time.Second
+
+// Pool — http.RoundTripper для сайта капперов поверх списка прокси.
+// Запросы идут через первый живой по приоритету прокси; при сетевой ошибке
+// пул переходит на следующий, а GET/HEAD повторяется через него.
+// Фоновая проверка возвращает восстановившиеся прокси.
+type Pool struct {
+	logger   *slog.Logger
+	cfg      config.ScraperProxyConfig
+	checkURL string
+
+	names      []string
+	transports []http.RoundTripper
+	direct     http.RoundTripper
+
+	mu     sync.Mutex
+	active int
+}
+
+var _ http.RoundTripper = (*Pool)(nil)
+
+// New готовит транспорты для всех прокси; checkURL — адрес для проверок живости
+func New(logger *slog.Logger, cfg config.ScraperProxyConfig, checkURL string) (*Pool, error) {
+	p := &Pool{
+		logger:   logger,
+		cfg:      cfg,
+		checkURL: checkURL,
+		direct:   http.DefaultTransport,
+		active:   direct,
+	}
+	for _, raw := range cfg.URLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy url: %w", err)
+		}
+		tr := http.DefaultTransport.(*http.Transport).Clone()
+		tr.Proxy = http.ProxyURL(u)
+		p.names = append(p.names, u.Redacted())
+		p.transports = append(p.transports, tr)
+	}
+	if len(p.transports) > 0 {
+		p.active = 0
+	}
+	return p, nil
+}
+
+// Start проверяет прокси сразу и далее раз в check_interval, пока жив ctx
+func (p *Pool) Start(ctx context.Context) {
+	if len(p.transports) == 0 {
+		p.logger.Info("Scraper proxy not configured, connecting directly")
+		return
+	}
+	p.check(ctx)
+	go func() {
+		ticker := time.NewTicker(p.cfg.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.check(ctx)
+			}
+		}
+	}()
+}
+
+func (p *Pool) RoundTrip(req *http.Request) (*http.Response, error) {
+	i := p.current()
+	resp, err := p.transport(i).RoundTrip(req)
+	if err == nil || i == direct || req.Context().Err() != nil {
+		return resp, err
+	}
+
+	next := p.fail(i, err)
+	if next == i || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return resp, err
+	}
+	return p.transport(next).RoundTrip(req)
+}
+
+// check включает первый по приоритету прокси, через который отвечает сайт
+func (p *Pool) check(ctx context.Context) {
+	for i := range p.transports {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := p.ping(ctx, i); err != nil {
+			p.logger.Warn("Scraper proxy check failed", "proxy", p.names[i], "error", err)
+			continue
+		}
+		p.use(i)
+		return
+	}
+	if p.cfg.DirectFallback {
+		p.use(direct)
+		return
+	}
+	p.logger.Error("No scraper proxy responds", "proxy", p.name(p.current()))
+}
+
+func (p *Pool) ping(ctx context.Context, i int) error {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, p.checkURL, http.NoBody)
+	if err != nil {
+		return err
+	}
+	resp, err := p.transport(i).RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// fail уходит с прокси i на следующий по списку (или напрямую) и возвращает его
+func (p *Pool) fail(i int, err error) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.active != i {
+		// Уже переключились из другого запроса
+		return p.active
+	}
+	next := i + 1
+	if next >= len(p.transports) {
+		next = 0
+		if p.cfg.DirectFallback {
+			next = direct
+		}
+	}
+	if next == i {
+		return i
+	}
+	p.active = next
+	p.logger.Warn("Scraper proxy failed, switched", "from", p.names[i], "to", p.name(next), "error", err)
+	return next
+}
+
+func (p *Pool) use(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.active == i {
+		return
+	}
+	p.logger.Warn("Scraper proxy switched", "from", p.name(p.active), "to", p.name(i))
+	p.active = i
+}
+
+func (p *Pool) current() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.active
+}
+
+func (p *Pool) transport(i int) http.RoundTripper {
+	if i == direct {
+		return p.direct
+	}
+	return p.transports[i]
+}
+
+func (p *Pool) name(i int) string {
+	if i == direct {
+		return "direct"
+	}
+	return p.names[i]
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+)
+
+var _ ports.DedupPersister = (*Storage)(nil)
+
+// LoadDedupKeys возвращает неистёкшие ключи дедупликации
+func (s *Storage) LoadDedupKeys(now time.Time) (map[string]time.Time, error) {
+	rows, err := s.db.Query(`SELECT key, expires_at FROM dedup_keys WHERE expires_at > ?`, now.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("load dedup keys: %w", err)
+	}
+	defer rows.Close()
+
+	res := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			key       string
+			expiresAt int64
+		)
+		if err := rows.Scan(&key, &expiresAt); err != nil {
+			return nil, err
+		}
+		res[key] = time.Unix(expiresAt, 0)
+	}
+	return res, rows.Err()
+}
+
+// SaveDedupKey сохраняет или продлевает ключ
+func (s *Storage) SaveDedupKey(key string, expiresAt time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO dedup_keys (key, expires_at) VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET expires_at = excluded.expires_at`, key, expiresAt.Unix())
+	if err != nil {
+		return fmt.Errorf("save dedup key: %w", err)
+	}
+	return nil
+}
+
+// DeleteDedupKey удаляет ключ
+func (s *Storage) DeleteDedupKey(key string) error {
+	if _, err := s.db.Exec(`DELETE FROM dedup_keys WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("delete dedup key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredDedupKeys чистит истёкшие ключи
+func (s *Storage) DeleteExpiredDedupKeys(now time.Time) error {
+	if _, err := s.db.Exec(`DELETE FROM dedup_keys WHERE expires_at <= ?`, now.Unix()); err != nil {
+		return fmt.Errorf("delete expired dedup keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+	"github.com/shopspring/decimal"
+)
+
+var _ ports.ForecastStore = (*Storage)(nil)
+
+// ErrNotFound — запись не найдена
+var ErrNotFound = ports.ErrNotFound
+
+const forecastColumns = `id, source_chat_id, source_message_id, parser, kind, capper, sport, league,
+	home_team, away_team, kickoff, coef, coef_approx, stake, legs, result, raw_text, photo_file,
+	outcome, outcome_coef, outcome_stake, bet_type, outcome_league, outcome_legs,
+	status, error, created_at, updated_at`
+
+// SaveForecast сохраняет разобранный анонс со статусом parsed
+func (s *Storage) SaveForecast(f *domain.Forecast) (int64, error) {
+	legs, err := json.Marshal(f.Legs)
+	if err != nil {
+		return 0, fmt.Errorf("marshal legs: %w", err)
+	}
+	now := time.Now().Unix()
+
+	res, err := s.db.Exec(`INSERT INTO forecasts (
+		source_chat_id, source_message_id, parser, kind, capper, sport, league,
+		home_team, away_team, kickoff, coef, coef_approx, stake, legs, result, raw_text, photo_file,
+		status, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		f.SourceChatID, f.SourceMessageID, f.Parser, string(f.Kind), f.Capper, f.Sport, f.League,
+		f.HomeTeam, f.AwayTeam, f.Kickoff.Unix(), f.Coef.String(), f.CoefApprox, f.Stake.String(), string(legs), f.Result, f.RawText, f.PhotoFile,
+		string(domain.StatusParsed), now, now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insert forecast: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	f.ID = id
+	return id, nil
+}
+
+// SetOutcome сохраняет найденную ставку и переводит анонс в outcome_found.
+// Неизвестные кф и сумма хранятся пустой строкой.
+func (s *Storage) SetOutcome(id int64, o domain.Outcome) error {
+	legs, err := json.Marshal(o.Legs)
+	if err != nil {
+		return fmt.Errorf("marshal outcome legs: %w", err)
+	}
+	return s.update(id, `outcome = ?, outcome_coef = ?, outcome_stake = ?, bet_type = ?, outcome_league = ?, outcome_legs = ?, status = ?`,
+		o.Text, optionalDecimal(o.Coef), optionalDecimal(o.Stake), string(o.BetType), o.League, string(legs), string(domain.StatusOutcomeFound))
+}
+
+func optionalDecimal(d decimal.Decimal) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.String()
+}
+
+// MarkSent запоминает пост в целевом канале для правок и удалений.
+// Статус не меняется: его выставляет конвейер, когда обработаны все каналы.
+func (s *Storage) MarkSent(id, targetChatID, sentMessageID int64) error {
+	if _, err := s.db.Exec(`INSERT OR REPLACE INTO sent_messages (forecast_id, target_chat_id, message_id, created_at)
+		VALUES (?, ?, ?, ?)`, id, targetChatID, sentMessageID, time.Now().Unix()); err != nil {
+		return fmt.Errorf("insert sent message of forecast %d: %w", id, err)
+	}
+	return nil
+}
+
+// ListSent возвращает все посты анонса в целевых каналах
+func (s *Storage) ListSent(id int64) ([]domain.SentMessage, error) {
+	rows, err := s.db.Query(`SELECT forecast_id, target_chat_id, message_id FROM sent_messages
+		WHERE forecast_id = ? ORDER BY created_at`, id)
+	if err != nil {
+		return nil, fmt.Errorf("list sent messages of forecast %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	var res []domain.SentMessage
+	for rows.Next() {
+		var m domain.SentMessage
+		if err := rows.Scan(&m.ForecastID, &m.ChatID, &m.MessageID); err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+	return res, rows.Err()
+}
+
+// UpdateForecast перезаписывает разобранные поля анонса после правки источника;
+// статус, исход и отправки не меняются
+func (s *Storage) UpdateForecast(f *domain.Forecast) error {
+	legs, err := json.Marshal(f.Legs)
+	if err != nil {
+		return fmt.Errorf("marshal legs: %w", err)
+	}
+	return s.update(f.ID, `parser = ?, kind = ?, capper = ?, sport = ?, league = ?, home_team = ?, away_team = ?,
+		kickoff = ?, coef = ?, coef_approx = ?, stake = ?, legs = ?, result = ?, raw_text = ?, photo_file = ?`,
+		f.Parser, string(f.Kind), f.Capper, f.Sport, f.League, f.HomeTeam, f.AwayTeam,
+		f.Kickoff.Unix(), f.Coef.String(), f.CoefApprox, f.Stake.String(), string(legs), f.Result, f.RawText, f.PhotoFile)
+}
+
+// SetStatus меняет статус; reason сохраняется как текст ошибки/причины пропуска
+func (s *Storage) SetStatus(id int64, status domain.ForecastStatus, reason string) error {
+	return s.update(id, `status = ?, error = ?`, string(status), reason)
+}
+
+func (s *Storage) update(id int64, set string, args ...any) error {
+	args = append(args, time.Now().Unix(), id)
+	res, err := s.db.Exec(`UPDATE forecasts SET `+set+`, updated_at = ? WHERE id = ?`, args...)
+	if err != nil {
+		return fmt.Errorf("update forecast %d: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("forecast %d: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// GetForecast возвращает анонс по ID
+func (s *Storage) GetForecast(id int64) (*domain.ForecastRecord, error) {
+	row := s.db.QueryRow(`SELECT `+forecastColumns+` FROM forecasts WHERE id = ?`, id)
+	rec, err := scanForecast(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("forecast %d: %w", id, ErrNotFound)
+	}
+	return rec, err
+}
+
+// FindBySource возвращает последний анонс, разобранный из сообщения источника
+func (s *Storage) FindBySource(chatID, messageID int64) (*domain.ForecastRecord, error) {
+	row := s.db.QueryRow(`SELECT `+forecastColumns+` FROM forecasts
+		WHERE source_chat_id = ? AND source_message_id = ? ORDER BY id DESC LIMIT 1`, chatID, messageID)
+	rec, err := scanForecast(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("forecast from message %d/%d: %w", chatID, messageID, ErrNotFound)
+	}
+	return rec, err
+}
+
+// ListByStatus возвращает до limit анонсов с заданным статусом, старые первыми
+func (s *Storage) ListByStatus(status domain.ForecastStatus, limit int) ([]domain.ForecastRecord, error) {
+	rows, err := s.db.Query(`SELECT `+forecastColumns+` FROM forecasts WHERE status = ? ORDER BY id LIMIT ?`, string(status), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list forecasts: %w", err)
+	}
+	defer rows.Close()
+
+	var res []domain.ForecastRecord
+	for rows.Next() {
+		rec, err := scanForecast(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *rec)
+	}
+	return res, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanForecast(row scanner) (*domain.ForecastRecord, error) {
+	var (
+		rec                                             domain.ForecastRecord
+		f                                               = &rec.Forecast
+		kind, coef, stake, legs, status                 string
+		outcomeCoef, outcomeStake, betType, outcomeLegs string
+		kickoff, createdAt, updatedAt                   int64
+	)
+	err := row.Scan(
+		&f.ID, &f.SourceChatID, &f.SourceMessageID, &f.Parser, &kind, &f.Capper, &f.Sport, &f.League,
+		&f.HomeTeam, &f.AwayTeam, &kickoff, &coef, &f.CoefApprox, &stake, &legs, &f.Result, &f.RawText, &f.PhotoFile,
+		&rec.Outcome.Text, &outcomeCoef, &outcomeStake, &betType, &rec.Outcome.League, &outcomeLegs,
+		&status, &rec.Error, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Kind = domain.AnnouncementKind(kind)
+	f.Kickoff = time.Unix(kickoff, 0)
+	f.Coef, _ = decimal.NewFromString(coef)
+	f.Stake, _ = decimal.NewFromString(stake)
+	if err := json.Unmarshal([]byte(legs), &f.Legs); err != nil {
+		return nil, fmt.Errorf("unmarshal legs of forecast %d: %w", f.ID, err)
+	}
+	rec.Outcome.Coef, _ = decimal.NewFromString(outcomeCoef)
+	rec.Outcome.Stake, _ = decimal.NewFromString(outcomeStake)
+	rec.Outcome.BetType = domain.BetType(betType)
+	if err := json.Unmarshal([]byte(outcomeLegs), &rec.Outcome.Legs); err != nil {
+		return nil, fmt.Errorf("unmarshal outcome legs of forecast %d: %w", f.ID, err)
+	}
+	rec.Status = domain.ForecastStatus(status)
+	rec.CreatedAt = time.Unix(createdAt, 0)
+	rec.UpdatedAt = time.Unix(updatedAt, 0)
+	return &rec, nil
+}
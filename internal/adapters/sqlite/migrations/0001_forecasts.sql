@@ -0,0 +1,30 @@
+CREATE TABLE forecasts (
+    id                INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_chat_id    INTEGER NOT NULL,
+    source_message_id INTEGER NOT NULL,
+    parser            TEXT    NOT NULL,
+    kind              TEXT    NOT NULL,
+    capper            TEXT    NOT NULL,
+    sport             TEXT    NOT NULL DEFAULT '',
+    league            TEXT    NOT NULL DEFAULT '',
+    home_team         TEXT    NOT NULL DEFAULT '',
+    away_team         TEXT    NOT NULL DEFAULT '',
+    kickoff           INTEGER NOT NULL,
+    coef              TEXT    NOT NULL DEFAULT '',
+    coef_approx       INTEGER NOT NULL DEFAULT 0,
+    stake             TEXT    NOT NULL DEFAULT '',
+    legs              TEXT    NOT NULL DEFAULT '[]',
+    result            TEXT    NOT NULL DEFAULT '',
+    raw_text          TEXT    NOT NULL DEFAULT '',
+    outcome           TEXT    NOT NULL DEFAULT '',
+    target_chat_id    INTEGER NOT NULL DEFAULT 0,
+    sent_message_id   INTEGER NOT NULL DEFAULT 0,
+    status            TEXT    NOT NULL,
+    error             TEXT    NOT NULL DEFAULT '',
+    created_at        INTEGER NOT NULL,
+    updated_at        INTEGER NOT NULL
+);
+
+CREATE INDEX forecasts_status_idx ON forecasts (status);
+CREATE INDEX forecasts_source_idx ON forecasts (source_chat_id, source_message_id);
+CREATE INDEX forecasts_capper_kickoff_idx ON forecasts (capper, kickoff);
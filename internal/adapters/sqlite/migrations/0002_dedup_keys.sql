@@ -0,0 +1,6 @@
+CREATE TABLE dedup_keys (
+    key        TEXT PRIMARY KEY,
+    expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX dedup_keys_expires_idx ON dedup_keys (expires_at);
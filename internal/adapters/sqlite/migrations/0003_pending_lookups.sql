@@ -0,0 +1,7 @@
+CREATE TABLE pending_lookups (
+    forecast_id     INTEGER PRIMARY KEY REFERENCES forecasts (id) ON DELETE CASCADE,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL,
+    deadline        INTEGER NOT NULL,
+    last_error      TEXT    NOT NULL DEFAULT ''
+);
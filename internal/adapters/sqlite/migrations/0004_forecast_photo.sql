@@ -0,0 +1 @@
+ALTER TABLE forecasts ADD COLUMN photo_file TEXT NOT NULL DEFAULT '';
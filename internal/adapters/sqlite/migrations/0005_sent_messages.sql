@@ -0,0 +1,12 @@
+CREATE TABLE sent_messages (
+    forecast_id    INTEGER NOT NULL REFERENCES forecasts (id) ON DELETE CASCADE,
+    target_chat_id INTEGER NOT NULL,
+    message_id     INTEGER NOT NULL,
+    created_at     INTEGER NOT NULL,
+    PRIMARY KEY (target_chat_id, message_id)
+);
+
+CREATE INDEX sent_messages_forecast_idx ON sent_messages (forecast_id);
+
+INSERT INTO sent_messages (forecast_id, target_chat_id, message_id, created_at)
+SELECT id, target_chat_id, sent_message_id, updated_at FROM forecasts WHERE sent_message_id != 0;
@@ -0,0 +1,4 @@
+ALTER TABLE forecasts ADD COLUMN outcome_coef TEXT NOT NULL DEFAULT '';
+ALTER TABLE forecasts ADD COLUMN outcome_stake TEXT NOT NULL DEFAULT '';
+ALTER TABLE forecasts ADD COLUMN bet_type TEXT NOT NULL DEFAULT '';
+ALTER TABLE forecasts ADD COLUMN outcome_league TEXT NOT NULL DEFAULT '';
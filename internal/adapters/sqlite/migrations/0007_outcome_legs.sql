@@ -0,0 +1 @@
+ALTER TABLE forecasts ADD COLUMN outcome_legs TEXT NOT NULL DEFAULT '[]';
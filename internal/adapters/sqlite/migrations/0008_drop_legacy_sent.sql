@@ -0,0 +1,2 @@
+ALTER TABLE forecasts DROP COLUMN target_chat_id;
+ALTER TABLE forecasts DROP COLUMN sent_message_id;
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+)
+
+var _ ports.PendingStore = (*Storage)(nil)
+
+// SavePending создаёт или обновляет отложенный поиск исхода
+func (s *Storage) SavePending(l domain.PendingLookup) error {
+	_, err := s.db.Exec(`INSERT INTO pending_lookups (forecast_id, attempts, next_attempt_at, deadline, last_error)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (forecast_id) DO UPDATE SET
+			attempts = excluded.attempts,
+			next_attempt_at = excluded.next_attempt_at,
+			deadline = excluded.deadline,
+			last_error = excluded.last_error`,
+		l.ForecastID, l.Attempts, l.NextAttempt.Unix(), l.Deadline.Unix(), l.LastError)
+	if err != nil {
+		return fmt.Errorf("save pending lookup %d: %w", l.ForecastID, err)
+	}
+	return nil
+}
+
+// DeletePending удаляет отложенный поиск
+func (s *Storage) DeletePending(forecastID int64) error {
+	if _, err := s.db.Exec(`DELETE FROM pending_lookups WHERE forecast_id = ?`, forecastID); err != nil {
+		return fmt.Errorf("delete pending lookup %d: %w", forecastID, err)
+	}
+	return nil
+}
+
+// ListPending возвращает все отложенные поиски
+func (s *Storage) ListPending() ([]domain.PendingLookup, error) {
+	rows, err := s.db.Query(`SELECT forecast_id, attempts, next_attempt_at, deadline, last_error
+		FROM pending_lookups ORDER BY next_attempt_at`)
+	if err != nil {
+		return nil, fmt.Errorf("list pending lookups: %w", err)
+	}
+	defer rows.Close()
+
+	var res []domain.PendingLookup
+	for rows.Next() {
+		var (
+			l                  domain.PendingLookup
+			nextAttempt, dline int64
+		)
+		if err := rows.Scan(&l.ForecastID, &l.Attempts, &nextAttempt, &dline, &l.LastError); err != nil {
+			return nil, err
+		}
+		l.NextAttempt = time.Unix(nextAttempt, 0)
+		l.Deadline = time.Unix(dline, 0)
+		res = append(res, l)
+	}
+	return res, rows.Err()
+}
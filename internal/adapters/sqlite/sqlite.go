@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // pure-Go драйвер, cgo нужен только для TDLib
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// Storage — хранилище бота поверх SQLite
+type Storage struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// New открывает (или создаёт) базу по пути path и применяет миграции
+func New(logger *slog.Logger, path string) (*Storage, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create storage dir: %w", err)
+		}
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	// SQLite не любит параллельных писателей
+	db.SetMaxOpenConns(1)
+
+	s := &Storage{db: db, logger: logger}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close закрывает базу
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// migrate применяет ещё не применённые файлы migrations/NNNN_*.sql по порядку номеров
+func (s *Storage) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	applied := make(map[int]bool)
+	rows, err := s.db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("read schema_migrations: %w", err)
+	}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[v] = true
+	}
+	rows.Close()
+
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	for _, e := range entries {
+		num, _, _ := strings.Cut(e.Name(), "_")
+		version, err := strconv.Atoi(num)
+		if err != nil {
+			return fmt.Errorf("bad migration name %q", e.Name())
+		}
+		if applied[version] {
+			continue
+		}
+
+		body, err := migrationsFS.ReadFile("migrations/" + e.Name())
+		if err != nil {
+			return err
+		}
+
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(string(body)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("apply migration %s: %w", e.Name(), err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().Unix()); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		s.logger.Info("Storage migration applied", "migration", e.Name())
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package sqlite
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// open открывает базу по path и закрывает её по окончании теста
+func open(t *testing.T, path string) *Storage {
+	t.Helper()
+	s, err := New(testLogger(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func testForecast(messageID int64) *domain.Forecast {
+	return &domain.Forecast{
+		Kind:            domain.KindNewForecast,
+		Parser:          "new_forecast",
+		Capper:          "Tester",
+		Sport:           "Футбол",
+		League:          "АПЛ",
+		HomeTeam:        "Арсенал",
+		AwayTeam:        "Челси",
+		Kickoff:         time.Date(2026, 3, 1, 21, 0, 0, 0, time.UTC),
+		Coef:            decimal.RequireFromString("1.85"),
+		SourceChatID:    7,
+		SourceMessageID: messageID,
+		RawText:         "Арсенал - Челси",
+	}
+}
+
+func columns(t *testing.T, s *Storage, table string) map[string]bool {
+	t.Helper()
+	rows, err := s.db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	res := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		res[name] = true
+	}
+	return res
+}
+
+func TestMigrateEmptyDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "pipebot.db")
+	s := open(t, path)
+
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(entries) {
+		t.Errorf("applied %d migrations, want %d", applied, len(entries))
+	}
+
+	cols := columns(t, s, "forecasts")
+	for _, c := range []string{"photo_file", "outcome_coef", "outcome_legs", "status"} {
+		if !cols[c] {
+			t.Errorf("forecasts has no column %s", c)
+		}
+	}
+	for _, c := range []string{"target_chat_id", "sent_message_id"} {
+		if cols[c] {
+			t.Errorf("legacy column %s is still in forecasts", c)
+		}
+	}
+	for _, table := range []string{"dedup_keys", "pending_lookups", "sent_messages"} {
+		if len(columns(t, s, table)) == 0 {
+			t.Errorf("table %s is missing", table)
+		}
+	}
+
+	// Повторное открытие ничего не применяет заново
+	s.Close()
+	s = open(t, path)
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(entries) {
+		t.Errorf("after reopen applied %d migrations, want %d", applied, len(entries))
+	}
+}
+
+func TestForecastStatusTransitions(t *testing.T) {
+	s := open(t, filepath.Join(t.TempDir(), "pipebot.db"))
+
+	f := testForecast(1)
+	id, err := s.SaveForecast(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.ID != id {
+		t.Fatalf("forecast ID = %d, want %d", f.ID, id)
+	}
+	rec, err := s.GetForecast(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Status != domain.StatusParsed {
+		t.Errorf("status = %s, want %s", rec.Status, domain.StatusParsed)
+	}
+	if rec.Forecast.Teams() != f.Teams() || !rec.Forecast.Kickoff.Equal(f.Kickoff) || !rec.Forecast.Coef.Equal(f.Coef) {
+		t.Errorf("forecast = %+v, want %+v", rec.Forecast, *f)
+	}
+
+	if err := s.SetStatus(id, domain.StatusPendingOutcome, "not found yet"); err != nil {
+		t.Fatal(err)
+	}
+	outcome := domain.Outcome{
+		Text:    "П1",
+		Coef:    decimal.RequireFromString("1.9"),
+		BetType: domain.BetSingle,
+		League:  "Англия. Премьер-лига",
+	}
+	if err := s.SetOutcome(id, outcome); err != nil {
+		t.Fatal(err)
+	}
+	rec, err = s.GetForecast(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Status != domain.StatusOutcomeFound {
+		t.Errorf("status = %s, want %s", rec.Status, domain.StatusOutcomeFound)
+	}
+	if rec.Outcome.Text != "П1" || !rec.Outcome.Coef.Equal(outcome.Coef) || !rec.Outcome.Stake.IsZero() ||
+		rec.Outcome.BetType != domain.BetSingle || rec.Outcome.League != outcome.League {
+		t.Errorf("outcome = %+v, want %+v", rec.Outcome, outcome)
+	}
+
+	if err := s.SetStatus(id, domain.StatusFailed, "send failed"); err != nil {
+		t.Fatal(err)
+	}
+	failed, err := s.ListByStatus(domain.StatusFailed, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0].Forecast.ID != id || failed[0].Error != "send failed" {
+		t.Errorf("failed forecasts = %+v, want %d with its reason", failed, id)
+	}
+	// Исход при смене статуса не теряется
+	if failed[0].Outcome.Text != "П1" {
+		t.Errorf("outcome after status change = %q, want П1", failed[0].Outcome.Text)
+	}
+
+	if err := s.SetStatus(id+1, domain.StatusSent, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SetStatus of unknown forecast: err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.GetForecast(id + 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetForecast of unknown forecast: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestExpressOutcomeLegs(t *testing.T) {
+	s := open(t, filepath.Join(t.TempDir(), "pipebot.db"))
+
+	f := testForecast(1)
+	f.Kind = domain.KindExpress
+	f.Legs = []domain.Leg{
+		{League: "АПЛ", HomeTeam: "Арсенал", AwayTeam: "Челси", Kickoff: f.Kickoff},
+		{League: "Ла Лига", HomeTeam: "Реал", AwayTeam: "Барселона", Kickoff: f.Kickoff.Add(time.Hour)},
+	}
+	id, err := s.SaveForecast(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcome := domain.Outcome{
+		Text:    "П1; ТБ 2.5",
+		Coef:    decimal.RequireFromString("3.5"),
+		Stake:   decimal.RequireFromString("1000"),
+		BetType: domain.BetExpress,
+		Legs: []domain.LegOutcome{
+			{Text: "П1", Coef: decimal.RequireFromString("1.9")},
+			{Text: "ТБ 2.5", Coef: decimal.RequireFromString("1.85")},
+		},
+	}
+	if err := s.SetOutcome(id, outcome); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := s.GetForecast(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Forecast.Legs) != 2 || rec.Forecast.Legs[1].Teams() != "Реал - Барселона" {
+		t.Errorf("legs = %+v, want %+v", rec.Forecast.Legs, f.Legs)
+	}
+	if !rec.Outcome.Stake.Equal(outcome.Stake) || len(rec.Outcome.Legs) != 2 ||
+		rec.Outcome.Legs[1].Text != "ТБ 2.5" || !rec.Outcome.Legs[1].Coef.Equal(outcome.Legs[1].Coef) {
+		t.Errorf("outcome = %+v, want %+v", rec.Outcome, outcome)
+	}
+}
+
+// Правки и удаления источника находят анонс по сообщению, а посты — по анонсу
+func TestSentMessagesBySource(t *testing.T) {
+	s := open(t, filepath.Join(t.TempDir(), "pipebot.db"))
+
+	first := testForecast(1)
+	if _, err := s.SaveForecast(first); err != nil {
+		t.Fatal(err)
+	}
+	other := testForecast(2)
+	if _, err := s.SaveForecast(other); err != nil {
+		t.Fatal(err)
+	}
+	// Сообщение, разобранное заново, даёт новую запись; искать нужно её
+	latest := testForecast(1)
+	latest.HomeTeam = "Ливерпуль"
+	if _, err := s.SaveForecast(latest); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := s.FindBySource(7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Forecast.ID != latest.ID {
+		t.Errorf("FindBySource = forecast %d, want latest %d", rec.Forecast.ID, latest.ID)
+	}
+	if _, err := s.FindBySource(7, 3); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FindBySource of unknown message: err = %v, want ErrNotFound", err)
+	}
+
+	for _, m := range []domain.SentMessage{{ChatID: 100, MessageID: 10}, {ChatID: 200, MessageID: 20}} {
+		if err := s.MarkSent(latest.ID, m.ChatID, m.MessageID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Повторная отметка того же поста не дублирует его
+	if err := s.MarkSent(latest.ID, 100, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkSent(other.ID, 100, 11); err != nil {
+		t.Fatal(err)
+	}
+
+	sent, err := s.ListSent(latest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.SentMessage{
+		{ForecastID: latest.ID, ChatID: 100, MessageID: 10},
+		{ForecastID: latest.ID, ChatID: 200, MessageID: 20},
+	}
+	if len(sent) != len(want) {
+		t.Fatalf("ListSent = %+v, want %+v", sent, want)
+	}
+	got := map[domain.SentMessage]bool{}
+	for _, m := range sent {
+		got[m] = true
+	}
+	for _, m := range want {
+		if !got[m] {
+			t.Errorf("ListSent = %+v, missing %+v", sent, m)
+		}
+	}
+
+	// MarkSent статус не трогает
+	rec, err = s.GetForecast(latest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Status != domain.StatusParsed {
+		t.Errorf("status after MarkSent = %s, want %s", rec.Status, domain.StatusParsed)
+	}
+
+	if sent, err := s.ListSent(first.ID); err != nil || len(sent) != 0 {
+		t.Errorf("ListSent of unsent forecast = %+v, %v, want none", sent, err)
+	}
+	if err := s.MarkSent(latest.ID+10, 300, 30); err == nil {
+		t.Error("MarkSent of unknown forecast succeeded")
+	}
+}
+
+// Отложенные поиски переживают перезапуск
+func TestPendingLookupsReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipebot.db")
+	s := open(t, path)
+
+	now := time.Now().Truncate(time.Second)
+	var ids []int64
+	for i := int64(1); i <= 3; i++ {
+		f := testForecast(i)
+		if _, err := s.SaveForecast(f); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, f.ID)
+	}
+	lookups := []domain.PendingLookup{
+		{ForecastID: ids[0], Attempts: 1, NextAttempt: now.Add(2 * time.Minute), Deadline: now.Add(time.Hour), LastError: "not found"},
+		{ForecastID: ids[1], Attempts: 0, NextAttempt: now.Add(time.Minute), Deadline: now.Add(time.Hour)},
+		{ForecastID: ids[2], Attempts: 1, NextAttempt: now, Deadline: now.Add(time.Hour)},
+	}
+	for _, l := range lookups {
+		if err := s.SavePending(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Следующая попытка перезаписывает прошлую
+	lookups[0].Attempts, lookups[0].NextAttempt, lookups[0].LastError = 2, now.Add(3*time.Minute), "timeout"
+	if err := s.SavePending(lookups[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeletePending(ids[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Close()
+	s = open(t, path)
+
+	got, err := s.ListPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.PendingLookup{lookups[1], lookups[0]}
+	if len(got) != len(want) {
+		t.Fatalf("ListPending = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].ForecastID != want[i].ForecastID || got[i].Attempts != want[i].Attempts ||
+			!got[i].NextAttempt.Equal(want[i].NextAttempt) || !got[i].Deadline.Equal(want[i].Deadline) ||
+			got[i].LastError != want[i].LastError {
+			t.Errorf("ListPending[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
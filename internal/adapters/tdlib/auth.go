@@ -0,0 +1,240 @@
+package tdlib
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+	"github.com/zelenin/go-tdlib/client"
+)
+
+var (
+	_ client.AuthorizationStateHandler = (*Authorizer)(nil)
+	_ ports.Authenticator              = (*Authorizer)(nil)
+	_ ports.TelegramStatusProvider     = (*Authorizer)(nil)
+)
+
+const (
+	authMethodCode = "code"
+	authMethodQR   = "qr"
+)
+
+// Authorizer проводит вход в аккаунт без терминала. Телефон, код и пароль 2FA
+// берутся из конфига (env), из файлов или приходят через SubmitAuth (HTTP /auth).
+// При входе по QR ссылка пишется в лог и отдаётся в AuthStatus.
+// Сессия хранится в базе TDLib, поэтому после перезапуска ничего не спрашивается.
+type Authorizer struct {
+	ctx    context.Context
+	logger *slog.Logger
+	cfg    config.AuthConfig
+	// params задаёт NewClient перед запуском авторизации
+	params *client.SetTdlibParametersRequest
+
+	inputs map[domain.AuthField]chan string
+
+	mu       sync.Mutex
+	status   domain.AuthStatus
+	rejected map[domain.AuthField]string
+}
+
+// NewAuthorizer создаёт авторизатор; отмена ctx прерывает ожидание ввода
+func NewAuthorizer(ctx context.Context, logger *slog.Logger, cfg config.AuthConfig) *Authorizer {
+	return &Authorizer{
+		ctx:    ctx,
+		logger: logger,
+		cfg:    cfg,
+		inputs: map[domain.AuthField]chan string{
+			domain.AuthPhone:    make(chan string, 1),
+			domain.AuthCode:     make(chan string, 1),
+			domain.AuthPassword: make(chan string, 1),
+		},
+		rejected: make(map[domain.AuthField]string),
+	}
+}
+
+// Handle вызывается go-tdlib на каждое состояние авторизации до Ready
+func (a *Authorizer) Handle(c *client.Client, state client.AuthorizationState) error {
+	a.setState(state.AuthorizationStateType())
+
+	switch s := state.(type) {
+	case *client.AuthorizationStateWaitTdlibParameters:
+		_, err := c.SetTdlibParameters(a.params)
+		return err
+
+	case *client.AuthorizationStateWaitPhoneNumber:
+		if a.cfg.Method == authMethodQR {
+			_, err := c.RequestQrCodeAuthentication(&client.RequestQrCodeAuthenticationRequest{})
+			return err
+		}
+		return a.submit(domain.AuthPhone, a.cfg.Phone, a.cfg.PhoneFile, func(phone string) error {
+			_, err := c.SetAuthenticationPhoneNumber(&client.SetAuthenticationPhoneNumberRequest{
+				PhoneNumber: phone,
+				Settings:    &client.PhoneNumberAuthenticationSettings{},
+			})
+			return err
+		})
+
+	case *client.AuthorizationStateWaitCode:
+		return a.submit(domain.AuthCode, a.cfg.Code, a.cfg.CodeFile, func(code string) error {
+			_, err := c.CheckAuthenticationCode(&client.CheckAuthenticationCodeRequest{Code: code})
+			return err
+		})
+
+	case *client.AuthorizationStateWaitPassword:
+		return a.submit(domain.AuthPassword, a.cfg.Password, a.cfg.PasswordFile, func(password string) error {
+			_, err := c.CheckAuthenticationPassword(&client.CheckAuthenticationPasswordRequest{Password: password})
+			return err
+		})
+
+	case *client.AuthorizationStateWaitOtherDeviceConfirmation:
+		a.setQRLink(s.Link)
+		// Состояние не меняется, пока ссылку не подтвердят, — не крутим GetAuthorizationState вхолостую
+		select {
+		case <-a.ctx.Done():
+			return a.ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+
+	case *client.AuthorizationStateReady, *client.AuthorizationStateClosing, *client.AuthorizationStateClosed:
+		return nil
+	}
+
+	return client.NotSupportedAuthorizationState(state)
+}
+
+func (a *Authorizer) Close() {}
+
+// submit ждёт значение поля и отправляет его в TDLib. Отвергнутое значение
+// запоминается и повторно не используется, а TDLib остаётся в том же состоянии —
+// Authorize спросит снова, и можно прислать исправленное.
+func (a *Authorizer) submit(field domain.AuthField, static, file string, send func(string) error) error {
+	value, err := a.await(field, static, file)
+	if err != nil {
+		return err
+	}
+	if err := send(value); err != nil {
+		a.logger.Error("TDLib authorization input rejected", "field", field, "error", err)
+		a.mu.Lock()
+		a.rejected[field] = value
+		a.status.LastError = fmt.Sprintf("%s: %v", field, err)
+		a.mu.Unlock()
+		return nil
+	}
+	a.mu.Lock()
+	a.status.LastError = ""
+	a.mu.Unlock()
+	return nil
+}
+
+// await возвращает значение из конфига, файла или SubmitAuth — что появится первым
+func (a *Authorizer) await(field domain.AuthField, static, file string) (string, error) {
+	if v := strings.TrimSpace(static); v != "" && !a.isRejected(field, v) {
+		return v, nil
+	}
+
+	a.setWaiting(field)
+	defer a.setWaiting("")
+	a.logger.Warn("TDLib authorization is waiting for input", "field", field, "file", file, "endpoint", "POST /auth")
+
+	ticker := time.NewTicker(a.cfg.FilePollInterval)
+	defer ticker.Stop()
+	for {
+		if file != "" {
+			if v, err := readSecret(file); err == nil && v != "" && !a.isRejected(field, v) {
+				return v, nil
+			}
+		}
+		select {
+		case v := <-a.inputs[field]:
+			return v, nil
+		case <-a.ctx.Done():
+			return "", fmt.Errorf("authorization aborted while waiting for %s: %w", field, a.ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// SubmitAuth передаёт значение, введённое через HTTP
+func (a *Authorizer) SubmitAuth(field domain.AuthField, value string) error {
+	ch, ok := a.inputs[field]
+	if !ok {
+		return fmt.Errorf("unknown auth field %q", field)
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fmt.Errorf("empty %s", field)
+	}
+	select {
+	case ch <- value:
+		return nil
+	default:
+		return fmt.Errorf("%s already submitted and not consumed yet", field)
+	}
+}
+
+// AuthStatus возвращает текущий шаг авторизации
+func (a *Authorizer) AuthStatus() domain.AuthStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.status
+}
+
+// Status позволяет health-проверкам видеть состояние до создания клиента
+func (a *Authorizer) Status() domain.TelegramStatus {
+	st := a.AuthStatus()
+	return domain.TelegramStatus{
+		AuthState:  st.State,
+		Authorized: st.State == client.TypeAuthorizationStateReady,
+	}
+}
+
+func (a *Authorizer) isRejected(field domain.AuthField, value string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.rejected[field] == value
+}
+
+func (a *Authorizer) setState(state string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.status.State != state {
+		a.logger.Info("TDLib authorization state", "state", state)
+	}
+	a.status.State = state
+	if state != client.TypeAuthorizationStateWaitOtherDeviceConfirmation {
+		a.status.QRLink = ""
+	}
+}
+
+func (a *Authorizer) setWaiting(field domain.AuthField) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.status.Waiting = field
+}
+
+func (a *Authorizer) setQRLink(link string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.status.QRLink == link {
+		return
+	}
+	a.status.QRLink = link
+	a.logger.Warn("Confirm login by QR code: open the link or render it as QR and scan in Telegram → Settings → Devices", "link", link)
+}
+
+// readSecret читает значение из файла (docker secret и т.п.)
+func readSecret(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,255 @@
+package tdlib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/zelenin/go-tdlib/client"
+)
+
+// Верхняя граница для GetChats после полной загрузки списка
+const maxChats = 100000
+
+// channelDirectory — целевые каналы капперов: «<префикс> <каппер>».
+// Полностью пересобирается по таймеру и точечно обновляется по UpdateNewChat/UpdateChatTitle.
+type channelDirectory struct {
+	enabled bool
+	prefix  string
+
+	mu       sync.RWMutex
+	byCapper map[string]int64
+	byChat   map[int64]string
+}
+
+func newChannelDirectory(cfg config.DiscoveryConfig) *channelDirectory {
+	return &channelDirectory{
+		enabled:  !cfg.Disabled,
+		prefix:   strings.TrimSpace(cfg.TitlePrefix),
+		byCapper: make(map[string]int64),
+		byChat:   make(map[int64]string),
+	}
+}
+
+// capperFromTitle выделяет имя каппера из названия канала
+func (d *channelDirectory) capperFromTitle(title string) (string, bool) {
+	title = strings.TrimSpace(title)
+	lp := strings.ToLower(d.prefix) + " "
+	if !strings.HasPrefix(strings.ToLower(title), lp) {
+		return "", false
+	}
+	name := strings.TrimSpace(title[len(lp):])
+	name = strings.TrimRight(name, ",.;: \t")
+	return name, name != ""
+}
+
+// capperKey — ключ byCapper: имя в анонсе и в названии канала может отличаться регистром
+func capperKey(capper string) string {
+	return strings.ToLower(capper)
+}
+
+func (d *channelDirectory) lookup(capper string) (int64, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	chatID, ok := d.byCapper[capperKey(capper)]
+	return chatID, ok
+}
+
+func (d *channelDirectory) replace(routes map[string]int64) {
+	byCapper := make(map[string]int64, len(routes))
+	byChat := make(map[int64]string, len(routes))
+	for capper, chatID := range routes {
+		byCapper[capperKey(capper)] = chatID
+		byChat[chatID] = capper
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.byCapper = byCapper
+	d.byChat = byChat
+}
+
+// set привязывает канал к капперу; прежняя привязка канала (до переименования) снимается
+func (d *channelDirectory) set(chatID int64, capper string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if old, ok := d.byChat[chatID]; ok {
+		if old == capper {
+			return false
+		}
+		delete(d.byCapper, capperKey(old))
+	}
+	d.byCapper[capperKey(capper)] = chatID
+	d.byChat[chatID] = capper
+	return true
+}
+
+func (d *channelDirectory) remove(chatID int64) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	capper, ok := d.byChat[chatID]
+	if !ok {
+		return "", false
+	}
+	delete(d.byChat, chatID)
+	if key := capperKey(capper); d.byCapper[key] == chatID {
+		delete(d.byCapper, key)
+	}
+	return capper, true
+}
+
+// TargetChannel возвращает канал каппера, в который аккаунт может публиковать
+func (t *TDLibClient) TargetChannel(capper string) (int64, bool) {
+	return t.channels.lookup(capper)
+}
+
+// scanChannels обходит все чаты аккаунта и собирает каналы капперов с правом публикации
+func (t *TDLibClient) scanChannels() (map[string]int64, error) {
+	chatIDs, err := t.loadAllChats()
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make(map[string]int64)
+	for _, chatID := range chatIDs {
+		chat, err := t.client.GetChat(&client.GetChatRequest{ChatId: chatID})
+		if err != nil {
+			continue
+		}
+		capper, ok := t.targetChannel(chat)
+		if ok {
+			routes[capper] = chat.Id
+		}
+	}
+	return routes, nil
+}
+
+// targetChannel проверяет, что чат — канал каппера и в нём можно публиковать
+func (t *TDLibClient) targetChannel(chat *client.Chat) (string, bool) {
+	capper, ok := t.channels.capperFromTitle(chat.Title)
+	if !ok {
+		return "", false
+	}
+	// Только супергруппы/каналы
+	if _, ok := chat.Type.(*client.ChatTypeSupergroup); !ok {
+		return "", false
+	}
+	if err := t.checkPostRights(chat); err != nil {
+		t.logger.Warn("Target channel skipped: no post rights", "capper", capper, "chat_id", chat.Id, "title", chat.Title, "error", err)
+		return "", false
+	}
+	return capper, true
+}
+
+// checkPostRights — в канал может писать владелец или админ с правом публикации,
+// в супергруппу — любой участник, которому не запрещены сообщения
+func (t *TDLibClient) checkPostRights(chat *client.Chat) error {
+	typ := chat.Type.(*client.ChatTypeSupergroup)
+	sg, err := t.client.GetSupergroup(&client.GetSupergroupRequest{SupergroupId: typ.SupergroupId})
+	if err != nil {
+		return fmt.Errorf("get supergroup: %w", err)
+	}
+
+	switch st := sg.Status.(type) {
+	case *client.ChatMemberStatusCreator:
+		return nil
+	case *client.ChatMemberStatusAdministrator:
+		if !typ.IsChannel || (st.Rights != nil && st.Rights.CanPostMessages) {
+			return nil
+		}
+		return errors.New("administrator without can_post_messages")
+	case *client.ChatMemberStatusMember:
+		if !typ.IsChannel && chat.Permissions != nil && chat.Permissions.CanSendBasicMessages {
+			return nil
+		}
+	case *client.ChatMemberStatusRestricted:
+		if !typ.IsChannel && st.IsMember && st.Permissions != nil && st.Permissions.CanSendBasicMessages {
+			return nil
+		}
+	}
+	return fmt.Errorf("member status %s", sg.Status.ChatMemberStatusType())
+}
+
+// refreshChannels пересобирает справочник; при ошибке остаётся прежний
+func (t *TDLibClient) refreshChannels() error {
+	routes, err := t.scanChannels()
+	if err != nil {
+		return fmt.Errorf("failed to scan target channels: %w", err)
+	}
+	t.channels.replace(routes)
+	t.logger.Info("Target channels resolved", "count", len(routes))
+	return nil
+}
+
+// refreshChannelsLoop периодически пересобирает справочник каналов
+func (t *TDLibClient) refreshChannelsLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			if err := t.refreshChannels(); err != nil {
+				t.logger.Error("Refresh target channels failed", "error", err)
+			}
+		}
+	}
+}
+
+// onChatChanged обновляет справочник при появлении или переименовании чата
+func (t *TDLibClient) onChatChanged(chat *client.Chat) {
+	capper, ok := t.targetChannel(chat)
+	if !ok {
+		if old, removed := t.channels.remove(chat.Id); removed {
+			t.logger.Info("Target channel removed", "capper", old, "chat_id", chat.Id, "title", chat.Title)
+		}
+		return
+	}
+	if t.channels.set(chat.Id, capper) {
+		t.logger.Info("Target channel added", "capper", capper, "chat_id", chat.Id, "title", chat.Title)
+	}
+}
+
+func (t *TDLibClient) onChatTitle(upd *client.UpdateChatTitle) {
+	chat, err := t.client.GetChat(&client.GetChatRequest{ChatId: upd.ChatId})
+	if err != nil {
+		t.logger.Error("Get renamed chat failed", "chat_id", upd.ChatId, "error", err)
+		return
+	}
+	chat.Title = upd.Title
+	t.onChatChanged(chat)
+}
+
+// loadAllChats догружает основной список и архив целиком: GetChats
+// отдаёт только уже загруженные чаты, LoadChats возвращает 404 в конце списка
+func (t *TDLibClient) loadAllChats() ([]int64, error) {
+	var ids []int64
+	seen := make(map[int64]struct{})
+	for _, list := range []client.ChatList{&client.ChatListMain{}, &client.ChatListArchive{}} {
+		for {
+			_, err := t.client.LoadChats(&client.LoadChatsRequest{ChatList: list, Limit: 100})
+			if err == nil {
+				continue
+			}
+			var respErr client.ResponseError
+			if errors.As(err, &respErr) && respErr.Err.Code == 404 {
+				break
+			}
+			return nil, fmt.Errorf("load chats: %w", err)
+		}
+		chats, err := t.client.GetChats(&client.GetChatsRequest{ChatList: list, Limit: maxChats})
+		if err != nil {
+			return nil, fmt.Errorf("get chats: %w", err)
+		}
+		for _, id := range chats.ChatIds {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
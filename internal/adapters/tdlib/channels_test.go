@@ -0,0 +1,38 @@
+package tdlib
+
+import (
+	"testing"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+)
+
+func TestChannelDirectoryIgnoresCase(t *testing.T) {
+	d := newChannelDirectory(config.DiscoveryConfig{TitlePrefix: "Прогнозы"})
+	d.replace(map[string]int64{"Tester": 1})
+
+	for _, capper := range []string{"Tester", "tester", "TESTER"} {
+		if chatID, ok := d.lookup(capper); !ok || chatID != 1 {
+			t.Errorf("lookup(%q) = %d, %v; want 1, true", capper, chatID, ok)
+		}
+	}
+
+	// Переименование канала с другим регистром не оставляет старую привязку
+	if !d.set(1, "TeStEr") {
+		t.Error("set after case-only rename = false, want true")
+	}
+	if chatID, ok := d.lookup("tester"); !ok || chatID != 1 {
+		t.Errorf("lookup after rename = %d, %v; want 1, true", chatID, ok)
+	}
+
+	d.set(2, "Другой Каппер")
+	if chatID, ok := d.lookup("другой каппер"); !ok || chatID != 2 {
+		t.Errorf("lookup of added channel = %d, %v; want 2, true", chatID, ok)
+	}
+
+	if capper, ok := d.remove(1); !ok || capper != "TeStEr" {
+		t.Errorf("remove = %q, %v; want TeStEr, true", capper, ok)
+	}
+	if _, ok := d.lookup("Tester"); ok {
+		t.Error("removed channel is still found")
+	}
+}
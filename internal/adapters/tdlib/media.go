@@ -0,0 +1,94 @@
+package tdlib
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/zelenin/go-tdlib/client"
+)
+
+// photoMessage передаёт подпись к фото как текст сообщения, а наибольший размер
+// фото — как PhotoFileID. Скачивает его конвейер, если подпись окажется анонсом:
+// загрузка здесь задерживала бы все обновления TDLib.
+func (t *TDLibClient) photoMessage(content *client.MessagePhoto, msgId, msgChatId int64) domain.Message {
+	msg := domain.Message{
+		ID:     msgId,
+		ChatID: msgChatId,
+		Text:   captionText(content.Caption),
+	}
+	if sizes := content.Photo.Sizes; len(sizes) > 0 && !t.media.Disabled {
+		photo := sizes[len(sizes)-1].Photo
+		if err := t.checkSize(photo); err != nil {
+			t.logger.Warn("Photo skipped", "chat_id", msgChatId, "message_id", msgId, "error", err)
+		} else {
+			msg.PhotoFileID = photo.Id
+		}
+	}
+	t.logger.Debug("Received photo", "text", msg.Text, "file_id", msg.PhotoFileID)
+	return msg
+}
+
+// documentMessage — подпись к документу (скриншот, отправленный файлом, PDF купона);
+// сам документ не нужен
+func (t *TDLibClient) documentMessage(content *client.MessageDocument, msgId, msgChatId int64) domain.Message {
+	t.logger.Debug("Received document", "text", captionText(content.Caption), "mime_type", content.Document.MimeType)
+	return domain.Message{
+		ID:     msgId,
+		ChatID: msgChatId,
+		Text:   captionText(content.Caption),
+	}
+}
+
+func captionText(caption *client.FormattedText) string {
+	if caption == nil {
+		return ""
+	}
+	return caption.Text
+}
+
+// checkSize отсекает файлы больше media.max_size
+func (t *TDLibClient) checkSize(f *client.File) error {
+	size := f.Size
+	if size == 0 {
+		size = f.ExpectedSize
+	}
+	if t.media.MaxSize > 0 && size > t.media.MaxSize {
+		return fmt.Errorf("file %d is %d bytes, limit %d", f.Id, size, t.media.MaxSize)
+	}
+	return nil
+}
+
+// DownloadFile синхронно скачивает файл в files_dir и возвращает локальный путь.
+// Уже скачанный файл TDLib повторно не загружает.
+func (t *TDLibClient) DownloadFile(ctx context.Context, fileID int32) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	file, err := t.client.DownloadFile(&client.DownloadFileRequest{
+		FileId:      fileID,
+		Priority:    1,
+		Synchronous: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("download file %d: %w", fileID, err)
+	}
+	if file.Local == nil || !file.Local.IsDownloadingCompleted {
+		return "", fmt.Errorf("download file %d: not completed", fileID)
+	}
+	return file.Local.Path, nil
+}
+
+// inputContent собирает контент исходящего сообщения: фото с подписью или текст
+func inputContent(msg domain.OutgoingMessage) client.InputMessageContent {
+	if msg.PhotoFile != "" {
+		return &client.InputMessagePhoto{
+			Photo:   &client.InputFileLocal{Path: msg.PhotoFile},
+			Caption: toFormattedText(msg.Text.Truncate(domain.MaxCaptionLength)),
+		}
+	}
+	return &client.InputMessageText{
+		Text:       toFormattedText(msg.Text.Truncate(domain.MaxMessageLength)),
+		ClearDraft: true,
+	}
+}
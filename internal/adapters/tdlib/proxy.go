@@ -0,0 +1,191 @@
+package tdlib
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/zelenin/go-tdlib/client"
+)
+
+// direct — индекс «без прокси» в proxyManager.active
+const direct = -1
+
+// proxyManager держит в TDLib прокси из конфига и переключает их на лету:
+// активный проверяется PingProxy, при отказе включается следующий живой
+// по приоритету, при восстановлении — снова более приоритетный.
+type proxyManager struct {
+	logger *slog.Logger
+	cfg    config.TelegramProxyConfig
+
+	client *client.Client
+	ids    []int32 // ID в TDLib для cfg.List[i]
+	active int
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newProxyManager(logger *slog.Logger, cfg config.TelegramProxyConfig) *proxyManager {
+	return &proxyManager{
+		logger: logger,
+		cfg:    cfg,
+		active: direct,
+		stop:   make(chan struct{}),
+	}
+}
+
+// option настраивает прокси сразу после создания клиента, ещё до авторизации.
+// Поля меняются только в этой горутине и запущенном из неё loop.
+func (m *proxyManager) option() client.Option {
+	return func(c *client.Client) {
+		m.client = c
+		if err := m.sync(); err != nil {
+			m.logger.Error("TDLib proxy setup failed", "error", err)
+			return
+		}
+		if len(m.ids) == 0 {
+			m.logger.Info("Proxy not configured, connecting directly")
+			return
+		}
+		m.check()
+		go m.loop()
+	}
+}
+
+// sync заменяет сохранённые в базе TDLib прокси на список из конфига
+func (m *proxyManager) sync() error {
+	existing, err := m.client.GetProxies()
+	if err != nil {
+		return fmt.Errorf("get proxies: %w", err)
+	}
+	for _, p := range existing.Proxies {
+		if _, err := m.client.RemoveProxy(&client.RemoveProxyRequest{ProxyId: p.Id}); err != nil {
+			return fmt.Errorf("remove proxy %s:%d: %w", p.Server, p.Port, err)
+		}
+	}
+	if len(m.cfg.List) == 0 {
+		if _, err := m.client.DisableProxy(); err != nil {
+			return fmt.Errorf("disable proxy: %w", err)
+		}
+		return nil
+	}
+
+	for _, px := range m.cfg.List {
+		p, err := m.client.AddProxy(&client.AddProxyRequest{
+			Server: px.Server,
+			Port:   px.Port,
+			Enable: false,
+			Type:   proxyType(px),
+		})
+		if err != nil {
+			return fmt.Errorf("add proxy %s:%d: %w", px.Server, px.Port, err)
+		}
+		m.ids = append(m.ids, p.Id)
+	}
+	return nil
+}
+
+func proxyType(px config.TelegramProxy) client.ProxyType {
+	switch px.Type {
+	case "http":
+		return &client.ProxyTypeHttp{Username: px.User, Password: px.Password, HttpOnly: px.HTTPOnly}
+	case "mtproto":
+		return &client.ProxyTypeMtproto{Secret: px.Secret}
+	default:
+		return &client.ProxyTypeSocks5{Username: px.User, Password: px.Password}
+	}
+}
+
+func (m *proxyManager) loop() {
+	ticker := time.NewTicker(m.cfg.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+// check включает первый по приоритету отвечающий прокси
+func (m *proxyManager) check() {
+	candidates := make([]int, 0, len(m.ids)+1)
+	for i := range m.ids {
+		candidates = append(candidates, i)
+	}
+	if m.cfg.DirectFallback {
+		candidates = append(candidates, direct)
+	}
+
+	for _, i := range candidates {
+		select {
+		case <-m.stop:
+			return
+		default:
+		}
+		d, err := m.ping(i)
+		if err != nil {
+			m.logger.Warn("Telegram proxy check failed", "proxy", m.name(i), "error", err)
+			continue
+		}
+		m.logger.Debug("Telegram proxy alive", "proxy", m.name(i), "ping", d)
+		m.use(i)
+		return
+	}
+	if m.active == direct && !m.cfg.DirectFallback {
+		// Без прокси соединения нет вовсе: включаем основной и ждём, пока оживёт
+		m.use(0)
+	}
+	m.logger.Error("No Telegram proxy responds", "proxy", m.name(m.active))
+}
+
+func (m *proxyManager) ping(i int) (time.Duration, error) {
+	var id int32
+	if i != direct {
+		id = m.ids[i]
+	}
+	s, err := m.client.PingProxy(&client.PingProxyRequest{ProxyId: id})
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(s.Seconds * float64(time.Second)), nil
+}
+
+// use переключает TDLib на прокси i через EnableProxy/DisableProxy
+func (m *proxyManager) use(i int) {
+	prev := m.active
+	if i == prev {
+		return
+	}
+
+	var err error
+	if i == direct {
+		_, err = m.client.DisableProxy()
+	} else {
+		_, err = m.client.EnableProxy(&client.EnableProxyRequest{ProxyId: m.ids[i]})
+	}
+	if err != nil {
+		m.logger.Error("Telegram proxy switch failed", "proxy", m.name(i), "error", err)
+		return
+	}
+
+	m.active = i
+	m.logger.Warn("Telegram proxy switched", "from", m.name(prev), "to", m.name(i))
+}
+
+func (m *proxyManager) name(i int) string {
+	if i == direct {
+		return "direct"
+	}
+	px := m.cfg.List[i]
+	return fmt.Sprintf("%s://%s:%d", px.Type, px.Server, px.Port)
+}
+
+func (m *proxyManager) close() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
@@ -0,0 +1,111 @@
+package tdlib
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zelenin/go-tdlib/client"
+)
+
+const (
+	// sendConfirmTimeout — сколько ждать окончательного ID; загрузка фото может быть долгой
+	sendConfirmTimeout = 2 * time.Minute
+	// earlyResultTTL — сколько хранить подтверждение, пришедшее раньше, чем его начали ждать
+	earlyResultTTL = time.Minute
+)
+
+// sendTracker сопоставляет временные ID с окончательными. sendMessage возвращает
+// сообщение с временным ID, а настоящий приходит позже в UpdateMessageSendSucceeded;
+// именно по нему пост потом редактируется и удаляется.
+type sendTracker struct {
+	mu      sync.Mutex
+	waiting map[sendKey]chan sendResult
+	early   map[sendKey]sendResult
+}
+
+type sendKey struct {
+	chatID int64
+	tempID int64
+}
+
+type sendResult struct {
+	id  int64
+	err error
+	at  time.Time
+}
+
+func newSendTracker() *sendTracker {
+	return &sendTracker{
+		waiting: make(map[sendKey]chan sendResult),
+		early:   make(map[sendKey]sendResult),
+	}
+}
+
+// wait регистрирует ожидание; если подтверждение уже пришло, канал готов сразу
+func (s *sendTracker) wait(chatID, tempID int64) <-chan sendResult {
+	key := sendKey{chatID, tempID}
+	ch := make(chan sendResult, 1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if res, ok := s.early[key]; ok {
+		delete(s.early, key)
+		ch <- res
+		return ch
+	}
+	s.waiting[key] = ch
+	return ch
+}
+
+// cancel снимает ожидание, например по таймауту
+func (s *sendTracker) cancel(chatID, tempID int64) {
+	s.mu.Lock()
+	delete(s.waiting, sendKey{chatID, tempID})
+	s.mu.Unlock()
+}
+
+// resolve передаёт результат отправки ожидающему или откладывает его
+func (s *sendTracker) resolve(chatID, tempID, id int64, err error) {
+	key := sendKey{chatID, tempID}
+	res := sendResult{id: id, err: err, at: time.Now()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ch, ok := s.waiting[key]; ok {
+		delete(s.waiting, key)
+		ch <- res
+		return
+	}
+	for k, r := range s.early {
+		if res.at.Sub(r.at) > earlyResultTTL {
+			delete(s.early, k)
+		}
+	}
+	s.early[key] = res
+}
+
+// trackSends принимает подтверждения отправки до состояния Closed. У него свой
+// слушатель: Listen снимается по сигналу, а конвейер после этого ещё дописывает
+// очередь. Слушатель не закрывается — go-tdlib пишет в него, пока TDLib работает,
+// и закрытие раньше Closed может уронить процесс.
+func (t *TDLibClient) trackSends() {
+	listener := t.client.GetListener()
+	for update := range listener.Updates {
+		switch upd := update.(type) {
+		case *client.UpdateMessageSendSucceeded:
+			t.sends.resolve(upd.Message.ChatId, upd.OldMessageId, upd.Message.Id, nil)
+		case *client.UpdateMessageSendFailed:
+			t.sends.resolve(upd.Message.ChatId, upd.OldMessageId, 0, fmt.Errorf("send failed: %d %s", upd.Error.Code, upd.Error.Message))
+		case *client.UpdateAuthorizationState:
+			if isClosed(upd) {
+				return
+			}
+		}
+	}
+}
+
+// isClosed сообщает, что TDLib закрыта и больше не пришлёт обновлений
+func isClosed(upd *client.UpdateAuthorizationState) bool {
+	return upd.AuthorizationState.AuthorizationStateType() == client.TypeAuthorizationStateClosed
+}
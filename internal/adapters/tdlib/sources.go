@@ -0,0 +1,185 @@
+package tdlib
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/zelenin/go-tdlib/client"
+)
+
+// sourceFilter хранит множество разрешённых чатов-источников.
+// Чаты задаются ID, username или шаблоном названия и перерезолвятся периодически.
+type sourceFilter struct {
+	cfg      config.SourcesConfig
+	patterns []*regexp.Regexp
+
+	mu sync.RWMutex
+	// pinned — чаты, заданные ID или username
+	pinned map[int64]struct{}
+	// byTitle — чаты, уже проверенные по названию: true — подходит под шаблон.
+	// Отрицательный ответ тоже запоминается, чтобы не спрашивать TDLib на каждое сообщение.
+	byTitle map[int64]bool
+}
+
+func newSourceFilter(cfg config.SourcesConfig) (*sourceFilter, error) {
+	f := &sourceFilter{
+		cfg:     cfg,
+		pinned:  make(map[int64]struct{}),
+		byTitle: make(map[int64]bool),
+	}
+	for _, p := range cfg.TitlePatterns {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source title pattern %q: %w", p, err)
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	return f, nil
+}
+
+// matchAll — фильтр не настроен, пропускаем все чаты
+func (f *sourceFilter) matchAll() bool {
+	return f.cfg.Empty()
+}
+
+// lookup — известен ли ответ для чата без запроса к TDLib
+func (f *sourceFilter) lookup(chatID int64) (source, known bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if _, ok := f.pinned[chatID]; ok {
+		return true, true
+	}
+	source, known = f.byTitle[chatID]
+	return source, known
+}
+
+// checkTitle проверяет название чата по шаблонам и запоминает ответ
+func (f *sourceFilter) checkTitle(chatID int64, title string) bool {
+	match := f.matchTitle(title)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.byTitle[chatID] = match
+	return match
+}
+
+func (f *sourceFilter) replace(pinned map[int64]struct{}, byTitle map[int64]bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pinned = pinned
+	f.byTitle = byTitle
+}
+
+func (f *sourceFilter) matchTitle(title string) bool {
+	for _, re := range f.patterns {
+		if re.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSource сообщает, нужно ли пропускать обновления из чата дальше.
+// Чат, появившийся между обновлениями списка, проверяется по названию один раз.
+func (t *TDLibClient) isSource(chatID int64) bool {
+	if t.sources.matchAll() {
+		return true
+	}
+	if source, known := t.sources.lookup(chatID); known {
+		return source
+	}
+	if len(t.sources.patterns) == 0 {
+		return false
+	}
+	// Ошибку не запоминаем: проверим снова со следующим обновлением
+	title, err := t.getChatTitle(chatID)
+	if err != nil || !t.sources.checkTitle(chatID, title) {
+		return false
+	}
+	t.logger.Info("New source chat matched by title", "chat_id", chatID, "title", title)
+	return true
+}
+
+// onSourceTitle перепроверяет переименованный чат по шаблонам названий
+func (t *TDLibClient) onSourceTitle(upd *client.UpdateChatTitle) {
+	if t.sources.matchAll() || len(t.sources.patterns) == 0 {
+		return
+	}
+	was, _ := t.sources.lookup(upd.ChatId)
+	if now := t.sources.checkTitle(upd.ChatId, upd.Title); now != was {
+		t.logger.Info("Source chat renamed", "chat_id", upd.ChatId, "title", upd.Title, "source", now)
+	}
+}
+
+// resolveSources заново собирает множество чатов-источников через TDLib
+func (t *TDLibClient) resolveSources() error {
+	if t.sources.matchAll() {
+		return nil
+	}
+
+	pinned := make(map[int64]struct{})
+	for _, id := range t.sources.cfg.ChatIDs {
+		pinned[id] = struct{}{}
+	}
+	byTitle := make(map[int64]bool)
+
+	var errs []string
+	for _, username := range t.sources.cfg.Usernames {
+		chat, err := t.client.SearchPublicChat(&client.SearchPublicChatRequest{
+			Username: username,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", username, err))
+			continue
+		}
+		pinned[chat.Id] = struct{}{}
+	}
+
+	if len(t.sources.patterns) > 0 {
+		chatIDs, err := t.loadAllChats()
+		if err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			for _, chatID := range chatIDs {
+				title, err := t.getChatTitle(chatID)
+				if err != nil {
+					continue
+				}
+				byTitle[chatID] = t.sources.matchTitle(title)
+			}
+		}
+	}
+
+	t.sources.replace(pinned, byTitle)
+	count := len(pinned)
+	for chatID, match := range byTitle {
+		if _, ok := pinned[chatID]; match && !ok {
+			count++
+		}
+	}
+	t.logger.Info("Source chats resolved", "count", count)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to resolve sources: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// refreshSources периодически перерезолвит источники
+func (t *TDLibClient) refreshSources(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			if err := t.resolveSources(); err != nil {
+				t.logger.Error("Refresh sources failed", "error", err)
+			}
+		}
+	}
+}
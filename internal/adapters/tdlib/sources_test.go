@@ -0,0 +1,43 @@
+package tdlib
+
+import (
+	"testing"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+)
+
+func TestSourceFilterCachesTitleChecks(t *testing.T) {
+	f, err := newSourceFilter(config.SourcesConfig{ChatIDs: []int64{1}, TitlePatterns: []string{"^прогнозы"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Как после resolveSources: заданный ID закреплён, названия ещё не проверялись
+	f.replace(map[int64]struct{}{1: {}}, map[int64]bool{})
+
+	if source, known := f.lookup(1); !source || !known {
+		t.Errorf("pinned chat: lookup = %v, %v; want true, true", source, known)
+	}
+	if _, known := f.lookup(2); known {
+		t.Error("unchecked chat is known before its title was checked")
+	}
+
+	if f.checkTitle(2, "Болталка") {
+		t.Error("title without the pattern matched")
+	}
+	if source, known := f.lookup(2); source || !known {
+		t.Errorf("rejected chat: lookup = %v, %v; want false, true", source, known)
+	}
+
+	// Переименование перепроверяет чат
+	if !f.checkTitle(2, "Прогнозы на спорт") {
+		t.Error("renamed chat did not match")
+	}
+	if source, _ := f.lookup(2); !source {
+		t.Error("renamed chat is not a source")
+	}
+
+	f.replace(map[int64]struct{}{1: {}}, map[int64]bool{})
+	if _, known := f.lookup(2); known {
+		t.Error("title check survived a full refresh")
+	}
+}
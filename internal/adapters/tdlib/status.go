@@ -0,0 +1,59 @@
+package tdlib
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/zelenin/go-tdlib/client"
+)
+
+// status — состояние клиента, которое обновляет Listen и читают health-проверки
+type status struct {
+	authState   atomic.Value // string
+	listening   atomic.Bool
+	lastUpdate  atomic.Int64 // unix nano
+	lastMessage atomic.Int64 // unix nano
+}
+
+func (s *status) setAuthState(state string) {
+	s.authState.Store(state)
+}
+
+func (s *status) touchUpdate() {
+	s.lastUpdate.Store(time.Now().UnixNano())
+}
+
+func (s *status) touchMessage() {
+	s.lastMessage.Store(time.Now().UnixNano())
+}
+
+// Status возвращает снимок состояния клиента
+func (t *TDLibClient) Status() domain.TelegramStatus {
+	state, _ := t.status.authState.Load().(string)
+	return domain.TelegramStatus{
+		AuthState:   state,
+		Authorized:  state == client.TypeAuthorizationStateReady,
+		Listening:   t.status.listening.Load(),
+		LastUpdate:  unixNano(t.status.lastUpdate.Load()),
+		LastMessage: unixNano(t.status.lastMessage.Load()),
+	}
+}
+
+// trackAuthState запоминает смену состояния авторизации
+func (t *TDLibClient) trackAuthState(upd *client.UpdateAuthorizationState) {
+	state := upd.AuthorizationState.AuthorizationStateType()
+	t.status.setAuthState(state)
+	if state == client.TypeAuthorizationStateReady {
+		t.logger.Info("TDLib authorization state changed", "state", state)
+	} else {
+		t.logger.Warn("TDLib authorization state changed", "state", state)
+	}
+}
+
+func unixNano(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
@@ -1,9 +1,11 @@
 package tdlib
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/larriantoniy/tg_pipe_bot/internal/config"
 	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
@@ -13,47 +15,51 @@ import (
 
 // TDLibClient реализует ports.TelegramClient через go-tdlib
 type TDLibClient struct {
-	client *client.Client
-	logger *slog.Logger
-	selfId int64
+	client   *client.Client
+	logger   *slog.Logger
+	selfId   int64
+	sources  *sourceFilter
+	channels *channelDirectory
+	status   status
+	proxies  *proxyManager
+	media    config.MediaConfig
+	sends    *sendTracker
+
+	// stop останавливает фоновые обновления при Close
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
-// NewClient создаёт и авторизует TDLib клиента
-func NewClient(logger *slog.Logger, cfg *config.Config) (ports.TelegramClient, error) {
-	tdParams := &client.SetTdlibParametersRequest{
-		ApiId:              cfg.APIID,
-		ApiHash:            cfg.APIHash,
+// NewClient создаёт TDLib клиента и авторизует его через auth
+func NewClient(logger *slog.Logger, cfg *config.Config, auth *Authorizer) (ports.TelegramClient, error) {
+	sources, err := newSourceFilter(cfg.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	auth.params = &client.SetTdlibParametersRequest{
+		ApiId:              cfg.Telegram.APIID,
+		ApiHash:            cfg.Telegram.APIHash,
 		SystemLanguageCode: "en",
 		DeviceModel:        "GoUserBot",
 		ApplicationVersion: "0.2",
 		UseMessageDatabase: true,
 		UseFileDatabase:    true,
-		DatabaseDirectory:  "./tdlib-db",
-		FilesDirectory:     "./tdlib-files",
+		DatabaseDirectory:  cfg.Telegram.DatabaseDir,
+		FilesDirectory:     cfg.Telegram.FilesDir,
 	}
 	if _, err := client.SetLogVerbosityLevel(&client.SetLogVerbosityLevelRequest{
 		NewVerbosityLevel: 1,
 	}); err != nil {
 		logger.Error("TDLib SetLogVerbosityLevel", "error", err)
 	}
-	// 1️⃣ Создаём authorizer и сразу запускаем CLI interactor,
-	// чтобы не зависнуть на WaitPhoneNumber/WaitCode внутри NewClient.
-	authorizer := client.ClientAuthorizer(tdParams)
-	go client.CliInteractor(authorizer)
-
-	tdClient, err := client.NewClient(
-		authorizer,
-		client.WithProxy(&client.AddProxyRequest{
-			Server: cfg.ProxyUrl,
-			Port:   cfg.ProxyPort,
-			Enable: true,
-			Type: &client.ProxyTypeSocks5{
-				Username: cfg.ProxyUser,
-				Password: cfg.ProxyPassword,
-			},
-		}),
-	)
+
+	proxies := newProxyManager(logger, cfg.Proxy.Telegram)
+
+	// Блокируется до авторизации; ввод приходит из env, файлов или HTTP /auth
+	tdClient, err := client.NewClient(auth, proxies.option())
 	if err != nil {
+		proxies.close()
 		return nil, err
 	}
 
@@ -61,16 +67,43 @@ func NewClient(logger *slog.Logger, cfg *config.Config) (ports.TelegramClient, e
 	me, err := tdClient.GetMe()
 	if err != nil {
 		logger.Error("GetMe failed", "error", err)
+		proxies.close()
 		return nil, err
 	}
 
 	logger.Info("TDLib authorized successfully", "self_id", me.Id)
 
-	return &TDLibClient{
-		client: tdClient,
-		logger: logger,
-		selfId: me.Id,
-	}, nil
+	t := &TDLibClient{
+		client:   tdClient,
+		logger:   logger,
+		selfId:   me.Id,
+		sources:  sources,
+		channels: newChannelDirectory(cfg.Routing.Discovery),
+		proxies:  proxies,
+		media:    cfg.Telegram.Media,
+		sends:    newSendTracker(),
+		stop:     make(chan struct{}),
+	}
+	t.status.setAuthState(client.TypeAuthorizationStateReady)
+	go t.trackSends()
+
+	if sources.matchAll() {
+		logger.Warn("No source chats configured, listening to all chats")
+	} else {
+		if err := t.resolveSources(); err != nil {
+			logger.Error("Resolve sources failed", "error", err)
+		}
+		go t.refreshSources(cfg.Sources.RefreshInterval)
+	}
+
+	if !cfg.Routing.Discovery.Disabled {
+		if err := t.refreshChannels(); err != nil {
+			logger.Error("Resolve target channels failed", "error", err)
+		}
+		go t.refreshChannelsLoop(cfg.Routing.Discovery.RefreshInterval)
+	}
+
+	return t, nil
 }
 
 // JoinChannel подписывается на публичный канал по его username, если ещё не подписан
@@ -98,21 +131,36 @@ func (t *TDLibClient) JoinChannel(username string) error {
 	return nil
 }
 
-// Listen возвращает канал доменных сообщений из TDLib и запускает обработку обновлений
-func (t *TDLibClient) Listen() (<-chan domain.Message, error) {
+// Listen возвращает канал доменных сообщений из TDLib и запускает обработку обновлений.
+// При отмене ctx канал закрывается, а слушатель дочитывается до состояния Closed:
+// go-tdlib пишет в него, пока TDLib работает, и закрывать его раньше нельзя.
+func (t *TDLibClient) Listen(ctx context.Context) (<-chan domain.Message, error) {
 	out := make(chan domain.Message)
 
 	// Получаем слушатель обновлений
 	listener := t.client.GetListener()
 	go func() {
+		t.status.listening.Store(true)
+		defer t.status.listening.Store(false)
 		defer close(out)
-		for update := range listener.Updates {
-
-			if upd, ok := update.(*client.UpdateNewMessage); ok {
-				_, err := t.ProcessUpdateNewMessage(out, upd)
-				if err != nil {
-					t.logger.Error("Error process UpdateNewMessage msg content type", "upd MessageContentType", upd.Message.Content.MessageContentType())
+		for {
+			select {
+			case <-ctx.Done():
+				go drainUntilClosed(listener)
+				return
+			case update, ok := <-listener.Updates:
+				if !ok {
+					return
 				}
+				t.status.touchUpdate()
+				if upd, ok := update.(*client.UpdateAuthorizationState); ok {
+					t.trackAuthState(upd)
+					if isClosed(upd) {
+						return
+					}
+					continue
+				}
+				t.handleUpdate(ctx, out, update)
 			}
 		}
 	}()
@@ -120,45 +168,67 @@ func (t *TDLibClient) Listen() (<-chan domain.Message, error) {
 	return out, nil
 }
 
-func (t *TDLibClient) GetAdminChannelsSimple() (map[string]string, error) {
-	const prefix = "Слив Платок " // вот тут ключевое изменение
-
-	res := make(map[string]string)
-
-	chats, err := t.client.GetChats(&client.GetChatsRequest{Limit: 1000})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get chats: %w", err)
-	}
-
-	lp := strings.ToLower(prefix)
-
-	for _, chatID := range chats.ChatIds {
-		chat, err := t.client.GetChat(&client.GetChatRequest{ChatId: chatID})
-		if err != nil {
-			continue
+// handleUpdate разбирает обновление TDLib и передаёт сообщения источников в out
+func (t *TDLibClient) handleUpdate(ctx context.Context, out chan<- domain.Message, update client.Type) {
+	switch upd := update.(type) {
+	case *client.UpdateNewChat:
+		if t.channels.enabled {
+			t.onChatChanged(upd.Chat)
 		}
-
-		// Только супергруппы/каналы
-		if _, ok := chat.Type.(*client.ChatTypeSupergroup); !ok {
-			continue
+	case *client.UpdateChatTitle:
+		t.onSourceTitle(upd)
+		if t.channels.enabled {
+			t.onChatTitle(upd)
 		}
-
-		title := strings.TrimSpace(chat.Title)
-		if !strings.HasPrefix(strings.ToLower(title), lp) {
-			continue
+	case *client.UpdateMessageContent:
+		if t.isSource(upd.ChatId) {
+			t.status.touchMessage()
+			if msg, ok := t.editedMessage(upd); ok {
+				emit(ctx, out, msg)
+			}
 		}
-
-		// Имя каппера после префикса
-		name := strings.TrimSpace(title[len(prefix):])
-		name = strings.TrimRight(name, ",.;: \t")
-		if name == "" {
-			continue
+	case *client.UpdateMessageEdited:
+		// Новое содержимое приходит отдельным UpdateMessageContent,
+		// здесь только дата правки и клавиатура
+		if t.isSource(upd.ChatId) {
+			t.logger.Debug("Source message edited", "chat_id", upd.ChatId, "message_id", upd.MessageId, "edit_date", upd.EditDate)
 		}
+	case *client.UpdateDeleteMessages:
+		// FromCache — TDLib лишь выгрузил сообщения из кэша, в чате они остались
+		if upd.IsPermanent && !upd.FromCache && t.isSource(upd.ChatId) {
+			t.status.touchMessage()
+			for _, id := range upd.MessageIds {
+				emit(ctx, out, domain.Message{Event: domain.MessageDeleted, ID: id, ChatID: upd.ChatId})
+			}
+		}
+	case *client.UpdateNewMessage:
+		if !t.isSource(upd.Message.ChatId) {
+			t.logger.Debug("Skip update from non-source chat", "chat_id", upd.Message.ChatId)
+			return
+		}
+		t.status.touchMessage()
+		if msg, ok := t.newMessage(upd); ok {
+			emit(ctx, out, msg)
+		}
+	}
+}
 
-		res[name] = fmt.Sprintf("%d", chat.Id)
+// emit передаёт сообщение, пока читатель Listen не ушёл по ctx
+func emit(ctx context.Context, out chan<- domain.Message, msg domain.Message) {
+	select {
+	case out <- msg:
+	case <-ctx.Done():
 	}
+}
 
-	return res, nil
+// drainUntilClosed дочитывает слушатель до состояния Closed,
+// чтобы go-tdlib не встал на заполненном буфере
+func drainUntilClosed(listener *client.Listener) {
+	for update := range listener.Updates {
+		if upd, ok := update.(*client.UpdateAuthorizationState); ok && isClosed(upd) {
+			return
+		}
+	}
 }
 
 func (t *TDLibClient) getChatTitle(chatID int64) (string, error) {
@@ -173,41 +243,67 @@ func (t *TDLibClient) getChatTitle(chatID int64) (string, error) {
 }
 
 func (t *TDLibClient) ProcessUpdateNewMessage(out chan domain.Message, upd *client.UpdateNewMessage) (<-chan domain.Message, error) {
+	if msg, ok := t.newMessage(upd); ok {
+		out <- msg
+	}
+	return out, nil
+}
+
+// newMessage строит доменное сообщение из нового сообщения источника
+func (t *TDLibClient) newMessage(upd *client.UpdateNewMessage) (domain.Message, bool) {
+	msg, ok := t.contentMessage(upd.Message.Content, upd.Message.Id, upd.Message.ChatId)
+	if !ok {
+		t.logger.Debug("cant switch type update", "upd message MessageContentType()", upd.Message.Content.MessageContentType())
+		return domain.Message{}, false
+	}
 	chatName, err := t.getChatTitle(upd.Message.ChatId)
 	if err != nil {
-		t.logger.Info("Error getting chat title", err)
+		t.logger.Info("Error getting chat title", "error", err)
 		chatName = ""
 	}
-	switch content := upd.Message.Content.(type) {
+	msg.ChatName = chatName
+	return msg, true
+}
+
+// editedMessage строит сообщение из нового содержимого изменённого сообщения источника
+func (t *TDLibClient) editedMessage(upd *client.UpdateMessageContent) (domain.Message, bool) {
+	msg, ok := t.contentMessage(upd.NewContent, upd.MessageId, upd.ChatId)
+	if !ok {
+		t.logger.Debug("Skip edit with unsupported content", "chat_id", upd.ChatId, "message_id", upd.MessageId, "content_type", upd.NewContent.MessageContentType())
+		return domain.Message{}, false
+	}
+	msg.Event = domain.MessageEdited
+	if chatName, err := t.getChatTitle(upd.ChatId); err == nil {
+		msg.ChatName = chatName
+	}
+	return msg, true
+}
+
+// contentMessage строит доменное сообщение из текста, фото или документа
+func (t *TDLibClient) contentMessage(content client.MessageContent, msgId, msgChatId int64) (domain.Message, bool) {
+	switch content := content.(type) {
 	case *client.MessageText:
-		return t.processMessageText(out, content, upd.Message.ChatId, chatName)
+		t.logger.Debug("Received message", "text", content.Text.Text)
+		return domain.Message{ID: msgId, ChatID: msgChatId, Text: content.Text.Text}, true
+	case *client.MessagePhoto:
+		return t.photoMessage(content, msgId, msgChatId), true
+	case *client.MessageDocument:
+		return t.documentMessage(content, msgId, msgChatId), true
 	default:
-		t.logger.Debug("cant switch type update", "upd message MessageContentType()", upd.Message.Content.MessageContentType())
-		return out, nil
+		return domain.Message{}, false
 	}
 }
 
-func (t *TDLibClient) processMessageText(out chan domain.Message, msg *client.MessageText, msgChatId int64, ChatName string) (<-chan domain.Message, error) {
-	t.logger.Debug("Received new message", "text", msg.Text.Text)
-	out <- domain.Message{
-		ChatID:   msgChatId,
-		Text:     msg.Text.Text,
-		ChatName: ChatName,
+func (t *TDLibClient) SendMessage(ctx context.Context, chatID int64, msg domain.OutgoingMessage) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
 	}
-	return out, nil
-}
-func (t *TDLibClient) SendMessage(chatID int64, text string) error {
 
 	// Формируем контент сообщения
-	content := &client.InputMessageText{
-		Text: &client.FormattedText{
-			Text: text,
-		},
-		ClearDraft: true,
-	}
+	content := inputContent(msg)
 	t.logger.Debug("Sending message", "chat_id", chatID, "content", content)
 	// Отправляем
-	_, err := t.client.SendMessage(&client.SendMessageRequest{
+	sent, err := t.client.SendMessage(&client.SendMessageRequest{
 		ChatId:              chatID,
 		InputMessageContent: content,
 	})
@@ -217,13 +313,114 @@ func (t *TDLibClient) SendMessage(chatID int64, text string) error {
 			"chatID", chatID,
 			"error", err,
 		)
-		return err
+		return 0, err
+	}
+
+	id := sent.Id
+	if sent.SendingState != nil {
+		if id, err = t.awaitSent(ctx, chatID, sent.Id); err != nil {
+			t.logger.Error("SendMessage failed", "chatID", chatID, "error", err)
+			return 0, err
+		}
 	}
 
 	t.logger.Info("Message sent",
 		"chatID", chatID,
-		"text", text,
+		"message_id", id,
+		"text", msg.Text.Text,
+		"photo", msg.PhotoFile,
 	)
 
+	return id, nil
+}
+
+// awaitSent ждёт, пока сервер примет сообщение, и возвращает его окончательный ID.
+// Если подтверждения нет, возвращается временный: сообщение, скорее всего, уйдёт,
+// и повторять отправку нельзя, но поправить или удалить пост потом не получится.
+func (t *TDLibClient) awaitSent(ctx context.Context, chatID, tempID int64) (int64, error) {
+	timer := time.NewTimer(sendConfirmTimeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-t.sends.wait(chatID, tempID):
+		return res.id, res.err
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	t.sends.cancel(chatID, tempID)
+	t.logger.Warn("Send confirmation not received, keeping temporary message id", "chatID", chatID, "message_id", tempID)
+	return tempID, nil
+}
+
+// EditMessage заменяет текст поста; у поста с фото меняется подпись
+func (t *TDLibClient) EditMessage(ctx context.Context, chatID, messageID int64, msg domain.OutgoingMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var err error
+	if msg.PhotoFile != "" {
+		_, err = t.client.EditMessageCaption(&client.EditMessageCaptionRequest{
+			ChatId:    chatID,
+			MessageId: messageID,
+			Caption:   toFormattedText(msg.Text.Truncate(domain.MaxCaptionLength)),
+		})
+	} else {
+		_, err = t.client.EditMessageText(&client.EditMessageTextRequest{
+			ChatId:    chatID,
+			MessageId: messageID,
+			InputMessageContent: &client.InputMessageText{
+				Text: toFormattedText(msg.Text.Truncate(domain.MaxMessageLength)),
+			},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("edit message %d in chat %d: %w", messageID, chatID, err)
+	}
+	t.logger.Info("Message edited", "chatID", chatID, "message_id", messageID)
 	return nil
 }
+
+// DeleteMessages удаляет посты у всех участников канала
+func (t *TDLibClient) DeleteMessages(ctx context.Context, chatID int64, messageIDs []int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := t.client.DeleteMessages(&client.DeleteMessagesRequest{
+		ChatId:     chatID,
+		MessageIds: messageIDs,
+		Revoke:     true,
+	}); err != nil {
+		return fmt.Errorf("delete messages %v in chat %d: %w", messageIDs, chatID, err)
+	}
+	t.logger.Info("Messages deleted", "chatID", chatID, "message_ids", messageIDs)
+	return nil
+}
+
+// Close останавливает фоновые задачи, закрывает TDLib и ждёт состояния Closed,
+// чтобы база tdlib-db была корректно сброшена на диск.
+func (t *TDLibClient) Close(ctx context.Context) error {
+	t.stopOnce.Do(func() { close(t.stop) })
+	t.proxies.close()
+
+	listener := t.client.GetListener()
+	if _, err := t.client.Close(); err != nil {
+		go drainUntilClosed(listener)
+		return fmt.Errorf("tdlib close: %w", err)
+	}
+	for {
+		select {
+		case update, ok := <-listener.Updates:
+			if !ok {
+				return nil
+			}
+			if upd, ok := update.(*client.UpdateAuthorizationState); ok && isClosed(upd) {
+				t.logger.Info("TDLib closed")
+				return nil
+			}
+		case <-ctx.Done():
+			go drainUntilClosed(listener)
+			return fmt.Errorf("tdlib close: %w", ctx.Err())
+		}
+	}
+}
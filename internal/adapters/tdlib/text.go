@@ -0,0 +1,109 @@
+package tdlib
+
+import (
+	"fmt"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+	"github.com/zelenin/go-tdlib/client"
+)
+
+// textParser разбирает разметку средствами TDLib. ParseTextEntities выполняется
+// синхронно и не требует авторизованного клиента, поэтому шаблоны
+// проверяются ещё до входа в аккаунт.
+type textParser struct{}
+
+// NewTextParser создаёт разборщик Markdown (MarkdownV2) и HTML
+func NewTextParser() ports.TextParser {
+	return textParser{}
+}
+
+func (textParser) ParseText(text, mode string) (domain.FormattedText, error) {
+	var parseMode client.TextParseMode
+	switch mode {
+	case "":
+		return domain.PlainText(text), nil
+	case "markdown":
+		parseMode = &client.TextParseModeMarkdown{Version: 2}
+	case "html":
+		parseMode = &client.TextParseModeHTML{}
+	default:
+		return domain.FormattedText{}, fmt.Errorf("unknown parse mode %q", mode)
+	}
+
+	ft, err := client.ParseTextEntities(&client.ParseTextEntitiesRequest{Text: text, ParseMode: parseMode})
+	if err != nil {
+		return domain.FormattedText{}, fmt.Errorf("parse %s: %w", mode, err)
+	}
+	return fromFormattedText(ft), nil
+}
+
+// fromFormattedText переводит сущности TDLib в доменные; неподдерживаемые
+// (упоминания, кастомные эмодзи) отбрасываются
+func fromFormattedText(ft *client.FormattedText) domain.FormattedText {
+	out := domain.FormattedText{Text: ft.Text}
+	for _, e := range ft.Entities {
+		de := domain.TextEntity{Offset: e.Offset, Length: e.Length}
+		switch t := e.Type.(type) {
+		case *client.TextEntityTypeBold:
+			de.Type = domain.EntityBold
+		case *client.TextEntityTypeItalic:
+			de.Type = domain.EntityItalic
+		case *client.TextEntityTypeUnderline:
+			de.Type = domain.EntityUnderline
+		case *client.TextEntityTypeStrikethrough:
+			de.Type = domain.EntityStrikethrough
+		case *client.TextEntityTypeSpoiler:
+			de.Type = domain.EntitySpoiler
+		case *client.TextEntityTypeCode:
+			de.Type = domain.EntityCode
+		case *client.TextEntityTypePre:
+			de.Type = domain.EntityPre
+		case *client.TextEntityTypePreCode:
+			de.Type, de.Language = domain.EntityPre, t.Language
+		case *client.TextEntityTypeBlockQuote:
+			de.Type = domain.EntityBlockQuote
+		case *client.TextEntityTypeTextUrl:
+			de.Type, de.URL = domain.EntityTextURL, t.Url
+		default:
+			continue
+		}
+		out.Entities = append(out.Entities, de)
+	}
+	return out
+}
+
+func toFormattedText(t domain.FormattedText) *client.FormattedText {
+	ft := &client.FormattedText{Text: t.Text}
+	for _, e := range t.Entities {
+		var typ client.TextEntityType
+		switch e.Type {
+		case domain.EntityBold:
+			typ = &client.TextEntityTypeBold{}
+		case domain.EntityItalic:
+			typ = &client.TextEntityTypeItalic{}
+		case domain.EntityUnderline:
+			typ = &client.TextEntityTypeUnderline{}
+		case domain.EntityStrikethrough:
+			typ = &client.TextEntityTypeStrikethrough{}
+		case domain.EntitySpoiler:
+			typ = &client.TextEntityTypeSpoiler{}
+		case domain.EntityCode:
+			typ = &client.TextEntityTypeCode{}
+		case domain.EntityPre:
+			if e.Language != "" {
+				typ = &client.TextEntityTypePreCode{Language: e.Language}
+			} else {
+				typ = &client.TextEntityTypePre{}
+			}
+		case domain.EntityBlockQuote:
+			typ = &client.TextEntityTypeBlockQuote{}
+		case domain.EntityTextURL:
+			typ = &client.TextEntityTypeTextUrl{Url: e.URL}
+		default:
+			continue
+		}
+		ft.Entities = append(ft.Entities, &client.TextEntity{Offset: e.Offset, Length: e.Length, Type: typ})
+	}
+	return ft
+}
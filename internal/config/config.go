@@ -1,95 +1,699 @@
 package config
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // в runtime-образе нет системной базы часовых поясов
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
-// Config хранит настройки приложения
+// Config хранит настройки приложения. Значения берутся из YAML-файла,
+// переменные окружения переопределяют их.
 type Config struct {
-	APIID          int32
-	APIHash        string
-	ProxyUrl       string
-	ProxyPort      int32
-	ProxyUser      string
-	ProxyPassword  string
-	BasePredictCh  string
-	BasePredictUrl string
-	Env            string `yaml:"env" env-required:"true"`
-}
-
-// Load читает настройки из переменных окружения
-func Load() (*Config, error) {
-	path := fetchConfigPath()
-	cfg := MustLoadPath(path)
-	apiIDStr := os.Getenv("TELEGRAM_API_ID")
-	apiHash := os.Getenv("TELEGRAM_API_HASH")
-	basePredictCh := os.Getenv("BASE_PREDICT_CH")
-	basePredictUrl := os.Getenv("BASE_PREDICTION_URL")
-	proxyUrl := os.Getenv("PROXY_URL")
-	proxyPassword := os.Getenv("PROXY_PASSWORD")
-	proxyUser := os.Getenv("PROXY_USER")
-	proxyPort := os.Getenv("PROXY_PORT")
-
-	if apiIDStr == "" || apiHash == "" || proxyUrl == "" || proxyPort == "" || proxyUser == "" || proxyPassword == "" {
-		return nil, fmt.Errorf("TELEGRAM_API_ID, TELEGRAM_API_HASH , PROXY_URL , PROXY_PORT , PROXY_USER ,PROXY_PASSWORD должны быть заданы")
-	}
-
-	apiID, err := strconv.Atoi(apiIDStr)
-	if err != nil {
-		return nil, fmt.Errorf("invalid TELEGRAM_API_ID: %w", err)
+	// Env — dev или prod, задаёт уровень логов
+	Env         string            `yaml:"env" env:"ENV"`
+	Telegram    TelegramConfig    `yaml:"telegram"`
+	Proxy       ProxyConfig       `yaml:"proxy"`
+	Scraper     ScraperConfig     `yaml:"scraper"`
+	Sources     SourcesConfig     `yaml:"sources"`
+	Time        TimeConfig        `yaml:"time"`
+	Storage     StorageConfig     `yaml:"storage"`
+	Dedup       DedupConfig       `yaml:"dedup"`
+	OutcomePoll OutcomePollConfig `yaml:"outcome_poll"`
+	Pipeline    PipelineConfig    `yaml:"pipeline"`
+	Parsers     ParsersConfig     `yaml:"parsers"`
+	Templates   TemplatesConfig   `yaml:"templates"`
+	HTTP        HTTPConfig        `yaml:"http"`
+	Routing     RoutingConfig     `yaml:"routing"`
+	// ShutdownTimeout — сколько ждать дообработки принятых сообщений при остановке
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"30s"`
+}
+
+// ProxyConfig — прокси для TDLib и для загрузки страниц капперов
+type ProxyConfig struct {
+	// Env — одиночный прокси из PROXY_*; при загрузке ставится первым в telegram.list
+	Env      EnvProxyConfig      `yaml:"-"`
+	Telegram TelegramProxyConfig `yaml:"telegram"`
+	Scraper  ScraperProxyConfig  `yaml:"scraper"`
+}
+
+// EnvProxyConfig — прокси TDLib, заданный переменными окружения
+type EnvProxyConfig struct {
+	Type     string `env:"PROXY_TYPE" env-default:"socks5"`
+	Server   string `env:"PROXY_URL"`
+	Port     int32  `env:"PROXY_PORT"`
+	User     string `env:"PROXY_USER"`
+	Password string `env:"PROXY_PASSWORD"`
+	Secret   string `env:"PROXY_SECRET"`
+}
+
+// TelegramProxyConfig — прокси TDLib в порядке приоритета. Активный проверяется
+// через PingProxy; при отказе включается следующий живой, при восстановлении —
+// снова более приоритетный. Пустой список — прямое соединение.
+type TelegramProxyConfig struct {
+	List          []TelegramProxy `yaml:"list"`
+	CheckInterval time.Duration   `yaml:"check_interval" env-default:"1m"`
+	// DirectFallback — если не отвечает ни один прокси, пробовать без прокси
+	DirectFallback bool `yaml:"direct_fallback"`
+}
+
+// TelegramProxy — прокси TDLib: socks5, http или mtproto
+type TelegramProxy struct {
+	Type     string `yaml:"type"`
+	Server   string `yaml:"server"`
+	Port     int32  `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	// Secret — секрет MTProto-прокси в hex
+	Secret string `yaml:"secret"`
+	// HTTPOnly — HTTP-прокси без поддержки CONNECT
+	HTTPOnly bool `yaml:"http_only"`
+}
+
+// ScraperProxyConfig — прокси для сайта капперов в порядке приоритета.
+// Живость проверяется запросом к scraper.base_url; при ошибке запроса
+// сразу выбирается следующий. Пустой список — напрямую.
+type ScraperProxyConfig struct {
+	// URLs — http://, https:// или socks5://, с user:password@ при необходимости
+	URLs          []string      `yaml:"urls" env:"SCRAPER_PROXIES" env-separator:","`
+	CheckInterval time.Duration `yaml:"check_interval" env-default:"1m"`
+	// DirectFallback — если не отвечает ни один прокси, ходить напрямую
+	DirectFallback bool `yaml:"direct_fallback"`
+}
+
+func (p *ProxyConfig) validate() error {
+	var errs []error
+	for i, px := range p.Telegram.List {
+		name := fmt.Sprintf("proxy.telegram.list[%d]", i)
+		switch px.Type {
+		case "socks5", "http":
+		case "mtproto":
+			if px.Secret == "" {
+				errs = append(errs, fmt.Errorf("%s: mtproto requires secret", name))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("%s: type want socks5, http or mtproto, got %q", name, px.Type))
+		}
+		if px.Server == "" {
+			errs = append(errs, fmt.Errorf("%s: server is required", name))
+		}
+		if px.Port <= 0 || px.Port > 65535 {
+			errs = append(errs, fmt.Errorf("%s: invalid port %d", name, px.Port))
+		}
+	}
+	if len(p.Telegram.List) > 0 && p.Telegram.CheckInterval <= 0 {
+		errs = append(errs, errors.New("proxy.telegram.check_interval: must be positive"))
 	}
-	proxyPortInt, err := strconv.Atoi(proxyPort)
-	if err != nil || proxyPortInt <= 0 || proxyPortInt > 65535 {
-		return nil, fmt.Errorf("invalid PROXY_PORT: %q", proxyPort)
+	for i, raw := range p.Scraper.URLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("proxy.scraper.urls[%d]: %w", i, err))
+			continue
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			errs = append(errs, fmt.Errorf("proxy.scraper.urls[%d]: unsupported scheme %q", i, u.Scheme))
+		}
+		if u.Host == "" {
+			errs = append(errs, fmt.Errorf("proxy.scraper.urls[%d]: host is required", i))
+		}
 	}
-	apiID32 := int32(apiID)
+	if len(p.Scraper.URLs) > 0 && p.Scraper.CheckInterval <= 0 {
+		errs = append(errs, errors.New("proxy.scraper.check_interval: must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// ScraperConfig — сайты со страницами капперов, где ищется исход ставки
+type ScraperConfig struct {
+	// BaseURL — основной сайт: по нему проверяется доступность, на него ведёт .CapperURL
+	// в шаблонах и на нём ищут провайдеры без своего base_url
+	BaseURL string        `yaml:"base_url" env:"BASE_PREDICTION_URL"`
+	Timeout time.Duration `yaml:"timeout" env:"SCRAPER_TIMEOUT" env-default:"10s"`
+	// Providers — сайты в порядке приоритета; пусто — один base_url с разметкой по умолчанию
+	Providers []OutcomeProviderConfig `yaml:"providers"`
+	// Cappers — свой порядок провайдеров для каппера: имя каппера → имена провайдеров
+	Cappers map[string][]string `yaml:"cappers"`
+	Cache   ScraperCacheConfig  `yaml:"cache"`
+}
 
-	return &Config{
-		APIID:          apiID32,
-		APIHash:        apiHash,
-		Env:            cfg.Env, // <— добавили
-		BasePredictUrl: basePredictUrl,
-		BasePredictCh:  basePredictCh,
-		ProxyUrl:       proxyUrl,
-		ProxyPort:      int32(proxyPortInt),
-		ProxyUser:      proxyUser,
-		ProxyPassword:  proxyPassword,
-	}, nil
+// ScraperCacheConfig — кэш страниц капперов. Повтор запроса той же страницы
+// в пределах TTL отдаётся из памяти, позже — перепроверяется по ETag/Last-Modified;
+// одновременные запросы одной страницы сливаются в один.
+type ScraperCacheConfig struct {
+	Disabled bool          `yaml:"disabled" env:"SCRAPER_CACHE_DISABLED"`
+	TTL      time.Duration `yaml:"ttl" env:"SCRAPER_CACHE_TTL" env-default:"15s"`
+	// MaxEntries — сколько страниц держать; при переполнении вытесняется самая старая
+	MaxEntries int `yaml:"max_entries" env-default:"200"`
 }
 
-func MustLoadPath(configPath string) *Config {
-	// check if file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		panic("config file does not exist: " + configPath)
+// OutcomeProviderConfig — сайт капперов: где лежит страница ставок и как её разбирать.
+// Незаполненные поля берутся из разметки основного сайта.
+type OutcomeProviderConfig struct {
+	Name    string `yaml:"name"`
+	BaseURL string `yaml:"base_url"`
+	// BetsPath — путь страницы ставок относительно base_url, {capper} заменяется именем каппера
+	BetsPath  string           `yaml:"bets_path"`
+	Selectors OutcomeSelectors `yaml:"selectors"`
+}
+
+// OutcomeSelectors — CSS-селекторы страницы ставок. Для полей ставки селекторы
+// пробуются по порядку, берётся первый непустой текст; поле, для которого
+// ничего не нашлось, остаётся пустым.
+type OutcomeSelectors struct {
+	// Bet — карточка ставки
+	Bet string `yaml:"bet"`
+	// Teams — названия команд внутри карточки: первый непустой элемент — хозяева, остальные — гости
+	Teams   string   `yaml:"teams"`
+	Outcome []string `yaml:"outcome"`
+	Coef    []string `yaml:"coef"`
+	Stake   []string `yaml:"stake"`
+	// BetType — ординар или экспресс
+	BetType []string         `yaml:"bet_type"`
+	League  []string         `yaml:"league"`
+	Express ExpressSelectors `yaml:"express"`
+}
+
+// ExpressSelectors — разметка карточки экспресса: селекторы событий
+// ищутся внутри каждого Event, TotalCoef — внутри всей карточки.
+// Пока Event не задан, экспрессы на сайте не ищутся и пропускаются со статусом skipped.
+type ExpressSelectors struct {
+	Event     string   `yaml:"event"`
+	Teams     string   `yaml:"teams"`
+	Outcome   []string `yaml:"outcome"`
+	Coef      []string `yaml:"coef"`
+	TotalCoef []string `yaml:"total_coef"`
+}
+
+// Разметка основного сайта
+const (
+	DefaultProviderName = "default"
+	DefaultBetsPath     = "{capper}/bets?_pjax=%23profile"
+)
+
+// DefaultSelectors — разметка карточки ставки основного сайта: исход и кф
+// в строке .exspres, сначала мобильная ячейка, если она пуста — десктопная.
+// Сумма, вид ставки, лига и события экспресса по умолчанию не ищутся:
+// их селекторы задаются в конфиге провайдера.
+var DefaultSelectors = OutcomeSelectors{
+	Bet:     ".UserBet",
+	Teams:   ".sides span",
+	Outcome: []string{".exspres .col-6.d-block.d-md-none.order-1", ".exspres .col-6.d-none.d-md-block.order-1"},
+	Coef:    []string{".exspres .col-6.d-block.d-md-none.order-2", ".exspres .col-6.d-none.d-md-block.order-2"},
+	Express: ExpressSelectors{
+		Teams:   ".sides span",
+		Outcome: []string{".col-6.d-block.d-md-none.order-1", ".col-6.d-none.d-md-block.order-1"},
+		Coef:    []string{".col-6.d-block.d-md-none.order-2", ".col-6.d-none.d-md-block.order-2"},
+	},
+}
+
+// withDefaults заполняет незаданные селекторы разметкой основного сайта
+func (s OutcomeSelectors) withDefaults() OutcomeSelectors {
+	if s.Bet == "" {
+		s.Bet = DefaultSelectors.Bet
+	}
+	if s.Teams == "" {
+		s.Teams = DefaultSelectors.Teams
+	}
+	if s.Express.Event == "" {
+		s.Express.Event = DefaultSelectors.Express.Event
+	}
+	if s.Express.Teams == "" {
+		s.Express.Teams = DefaultSelectors.Express.Teams
 	}
+	for _, f := range []struct{ dst, def *[]string }{
+		{&s.Outcome, &DefaultSelectors.Outcome},
+		{&s.Coef, &DefaultSelectors.Coef},
+		{&s.Stake, &DefaultSelectors.Stake},
+		{&s.BetType, &DefaultSelectors.BetType},
+		{&s.League, &DefaultSelectors.League},
+		{&s.Express.Outcome, &DefaultSelectors.Express.Outcome},
+		{&s.Express.Coef, &DefaultSelectors.Express.Coef},
+		{&s.Express.TotalCoef, &DefaultSelectors.Express.TotalCoef},
+	} {
+		if len(*f.dst) == 0 {
+			*f.dst = *f.def
+		}
+	}
+	return s
+}
 
-	var cfg Config
-	///
+// OutcomeProviders возвращает провайдеров в порядке приоритета с заполненными умолчаниями
+func (s *ScraperConfig) OutcomeProviders() []OutcomeProviderConfig {
+	if len(s.Providers) == 0 {
+		return []OutcomeProviderConfig{{Name: DefaultProviderName, BaseURL: s.BaseURL, BetsPath: DefaultBetsPath, Selectors: DefaultSelectors}}
+	}
+	out := make([]OutcomeProviderConfig, len(s.Providers))
+	for i, p := range s.Providers {
+		if p.BaseURL == "" {
+			p.BaseURL = s.BaseURL
+		}
+		if p.BetsPath == "" {
+			p.BetsPath = DefaultBetsPath
+		}
+		p.Selectors = p.Selectors.withDefaults()
+		out[i] = p
+	}
+	return out
+}
 
-	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		panic("cannot read config: " + err.Error())
+func (s *ScraperConfig) validate() error {
+	var errs []error
+	if err := validateURL(s.BaseURL); err != nil {
+		errs = append(errs, fmt.Errorf("scraper.base_url (BASE_PREDICTION_URL): %w", err))
+	}
+	if s.Timeout <= 0 {
+		errs = append(errs, errors.New("scraper.timeout: must be positive"))
 	}
+	if !s.Cache.Disabled && (s.Cache.TTL <= 0 || s.Cache.MaxEntries <= 0) {
+		errs = append(errs, fmt.Errorf("scraper.cache: ttl and max_entries must be positive, got %s and %d", s.Cache.TTL, s.Cache.MaxEntries))
+	}
+
+	names := make(map[string]struct{}, len(s.Providers))
+	for i, p := range s.Providers {
+		if p.Name == "" {
+			errs = append(errs, fmt.Errorf("scraper.providers[%d]: name is required", i))
+			continue
+		}
+		if _, ok := names[p.Name]; ok {
+			errs = append(errs, fmt.Errorf("scraper.providers[%s]: duplicate name", p.Name))
+		}
+		names[p.Name] = struct{}{}
+		if p.BaseURL != "" {
+			if err := validateURL(p.BaseURL); err != nil {
+				errs = append(errs, fmt.Errorf("scraper.providers[%s].base_url: %w", p.Name, err))
+			}
+		}
+		if p.BetsPath != "" && !strings.Contains(p.BetsPath, "{capper}") {
+			errs = append(errs, fmt.Errorf("scraper.providers[%s].bets_path: no {capper} placeholder", p.Name))
+		}
+	}
+	if len(s.Providers) == 0 {
+		names[DefaultProviderName] = struct{}{}
+	}
+	for capper, order := range s.Cappers {
+		if len(order) == 0 {
+			errs = append(errs, fmt.Errorf("scraper.cappers[%s]: empty provider list", capper))
+		}
+		for _, name := range order {
+			if _, ok := names[name]; !ok {
+				errs = append(errs, fmt.Errorf("scraper.cappers[%s]: unknown provider %q", capper, name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
 
-	return &cfg
+// PipelineConfig — размеры пулов воркеров и «человеческая» пауза перед отправкой
+type PipelineConfig struct {
+	ParseWorkers  int           `yaml:"parse_workers" env-default:"2"`
+	FetchWorkers  int           `yaml:"fetch_workers" env-default:"4"`
+	FormatWorkers int           `yaml:"format_workers" env-default:"2"`
+	QueueSize     int           `yaml:"queue_size" env-default:"256"`
+	SendDelayMin  time.Duration `yaml:"send_delay_min" env-default:"10s"`
+	SendDelayMax  time.Duration `yaml:"send_delay_max" env-default:"50s"`
 }
 
-// fetchConfigPath fetches config path from command line flag or environment variable.
-// Priority: flag > env > default.
-// Default value is empty string.
-func fetchConfigPath() string {
-	var res string
+// ParsersConfig — включение форматов анонсов
+type ParsersConfig struct {
+	// Disabled — имена выключенных форматов: new_forecast, edited_forecast, express, result
+	Disabled []string `yaml:"disabled"`
+}
+
+// TemplatesConfig — шаблоны text/template сообщений в целевые каналы
+type TemplatesConfig struct {
+	// Default — для каналов без своего шаблона; пусто — встроенный
+	Default string `yaml:"default"`
+	// ParseMode — разметка результата default: "" (обычный текст), markdown (MarkdownV2) или html
+	ParseMode string `yaml:"parse_mode"`
+	// AttachPhoto — если анонс пришёл подписью к фото, отправлять то же фото с текстом в подписи
+	AttachPhoto bool `yaml:"attach_photo"`
+	// Channels — шаблон по chat ID целевого канала
+	Channels map[int64]ChannelTemplate `yaml:"channels"`
+}
+
+// ChannelTemplate — шаблон канала и разметка его результата
+type ChannelTemplate struct {
+	Text        string `yaml:"text"`
+	ParseMode   string `yaml:"parse_mode"`
+	AttachPhoto bool   `yaml:"attach_photo"`
+}
+
+func (t *TemplatesConfig) validate() error {
+	var errs []error
+	if !validParseMode(t.ParseMode) {
+		errs = append(errs, fmt.Errorf("templates.parse_mode: want markdown or html, got %q", t.ParseMode))
+	}
+	for chatID, ch := range t.Channels {
+		if !validParseMode(ch.ParseMode) {
+			errs = append(errs, fmt.Errorf("templates.channels[%d].parse_mode: want markdown or html, got %q", chatID, ch.ParseMode))
+		}
+		if strings.TrimSpace(ch.Text) == "" {
+			errs = append(errs, fmt.Errorf("templates.channels[%d].text is required", chatID))
+		}
+	}
+	return errors.Join(errs...)
+}
 
-	flag.StringVar(&res, "config", "", "path to config file")
+func validParseMode(mode string) bool {
+	return mode == "" || mode == "markdown" || mode == "html"
+}
+
+// OutcomePollConfig — повторный поиск исхода, пока сайт каппера не обновился
+type OutcomePollConfig struct {
+	InitialDelay time.Duration `yaml:"initial_delay" env-default:"15s"`
+	MaxDelay     time.Duration `yaml:"max_delay" env-default:"5m"`
+}
+
+// DedupConfig — подавление повторных анонсов
+type DedupConfig struct {
+	TTL time.Duration `yaml:"ttl" env-default:"48h"`
+	// Persist сохраняет ключи в хранилище, чтобы дубли ловились и после перезапуска
+	Persist bool `yaml:"persist"`
+}
+
+// RoutingConfig — куда публиковать анонс. Цели всех подходящих правил и найденный
+// по названию канал каппера объединяются; если каппер нигде не упомянут — catch_all.
+type RoutingConfig struct {
+	Discovery DiscoveryConfig `yaml:"discovery"`
+	Rules     []RouteRule     `yaml:"rules"`
+	// CatchAll — каналы для капперов, которых нет ни в правилах, ни среди найденных каналов
+	CatchAll []int64 `yaml:"catch_all"`
+	// Sync — что делать с опубликованным постом при правке или удалении исходного;
+	// действует на найденные каналы, catch_all и правила без своей настройки
+	Sync SyncConfig `yaml:"sync"`
+}
+
+// SyncConfig — перенос правок и удалений исходного анонса в целевой канал
+type SyncConfig struct {
+	Edits   bool `yaml:"edits"`
+	Deletes bool `yaml:"deletes"`
+}
+
+// DiscoveryConfig — поиск каналов капперов по названию «<префикс> <каппер>»
+type DiscoveryConfig struct {
+	// Disabled оставляет только явные правила
+	Disabled    bool   `yaml:"disabled"`
+	TitlePrefix string `yaml:"title_prefix" env-default:"Слив Платок"`
+	// RefreshInterval — период полного пересбора; новые и переименованные каналы подхватываются сразу
+	RefreshInterval time.Duration `yaml:"refresh_interval" env-default:"10m"`
+}
+
+// RouteRule — правило маршрутизации. Пустой фильтр пропускает всё,
+// нулевая граница кф не проверяется.
+type RouteRule struct {
+	Name    string   `yaml:"name"`
+	Cappers []string `yaml:"cappers"`
+	Sports  []string `yaml:"sports"`
+	// Leagues сравниваются по вхождению подстроки без учёта регистра
+	Leagues []string `yaml:"leagues"`
+	MinCoef float64  `yaml:"min_coef"`
+	MaxCoef float64  `yaml:"max_coef"`
+	Targets []int64  `yaml:"targets"`
+	// Sync переопределяет routing.sync для целей правила
+	Sync *SyncConfig `yaml:"sync"`
+}
+
+func (r *RoutingConfig) validate() error {
+	var errs []error
+	if !r.Discovery.Disabled && r.Discovery.RefreshInterval <= 0 {
+		errs = append(errs, fmt.Errorf("routing.discovery.refresh_interval: must be positive, got %s", r.Discovery.RefreshInterval))
+	}
+	for i, rule := range r.Rules {
+		name := rule.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		if len(rule.Targets) == 0 {
+			errs = append(errs, fmt.Errorf("routing.rules[%s]: no targets", name))
+		}
+		if rule.MinCoef < 0 || rule.MaxCoef < 0 || (rule.MaxCoef > 0 && rule.MinCoef > rule.MaxCoef) {
+			errs = append(errs, fmt.Errorf("routing.rules[%s]: invalid coef range %v..%v", name, rule.MinCoef, rule.MaxCoef))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// TelegramConfig — сессия TDLib. Каталоги должны лежать на томах,
+// иначе после передеплоя придётся входить в аккаунт заново.
+type TelegramConfig struct {
+	APIID       int32       `yaml:"api_id" env:"TELEGRAM_API_ID"`
+	APIHash     string      `yaml:"api_hash" env:"TELEGRAM_API_HASH"`
+	DatabaseDir string      `yaml:"database_dir" env:"TDLIB_DATABASE_DIR" env-default:"./tdlib-db"`
+	FilesDir    string      `yaml:"files_dir" env:"TDLIB_FILES_DIR" env-default:"./tdlib-files"`
+	Auth        AuthConfig  `yaml:"auth"`
+	Media       MediaConfig `yaml:"media"`
+}
+
+// MediaConfig — вложения входящих сообщений. Подписи к фото и документам
+// разбираются всегда; фото разобранного анонса скачивается в files_dir.
+type MediaConfig struct {
+	// Disabled — не скачивать фото
+	Disabled bool `yaml:"disabled"`
+	// MaxSize — фото больше стольких байт не скачиваются; 0 — без ограничения
+	MaxSize int64 `yaml:"max_size" env-default:"20971520"`
+}
+
+// AuthConfig — вход в аккаунт без терминала. Каждое значение можно задать
+// в env, положить в файл (например, docker secret) или прислать на POST /auth.
+type AuthConfig struct {
+	// Method — code (телефон и код из Telegram) или qr (подтверждение ссылки с другого устройства)
+	Method       string `yaml:"method" env:"TG_AUTH_METHOD" env-default:"code"`
+	Phone        string `yaml:"phone" env:"TG_PHONE"`
+	PhoneFile    string `yaml:"phone_file" env:"TG_PHONE_FILE"`
+	Code         string `yaml:"-" env:"TG_CODE"`
+	CodeFile     string `yaml:"code_file" env:"TG_CODE_FILE"`
+	Password     string `yaml:"-" env:"TG_PASSWORD"`
+	PasswordFile string `yaml:"password_file" env:"TG_PASSWORD_FILE"`
+	// AdminToken — Bearer-токен для /auth; без него эндпоинт отключён
+	AdminToken       string        `yaml:"-" env:"TG_AUTH_ADMIN_TOKEN"`
+	FilePollInterval time.Duration `yaml:"file_poll_interval" env-default:"2s"`
+}
+
+// HTTPConfig — служебный HTTP-сервер (/metrics, /healthz, /readyz)
+type HTTPConfig struct {
+	Addr   string       `yaml:"addr" env:"HTTP_ADDR" env-default:":7230"`
+	Health HealthConfig `yaml:"health"`
+}
+
+// HealthConfig — пороги проверок живости и готовности
+type HealthConfig struct {
+	// MaxSilence — /healthz падает, если от TDLib нет обновлений дольше; 0 — не проверять
+	MaxSilence time.Duration `yaml:"max_silence" env-default:"0"`
+	// SiteCheckInterval — как долго /readyz использует прошлый результат проверки сайта
+	SiteCheckInterval time.Duration `yaml:"site_check_interval" env-default:"1m"`
+	SiteTimeout       time.Duration `yaml:"site_timeout" env-default:"5s"`
+}
+
+// StorageConfig — настройки SQLite-хранилища
+type StorageConfig struct {
+	Path string `yaml:"path" env:"STORAGE_PATH" env-default:"./data/pipebot.db"`
+}
+
+// SourcesConfig описывает чаты, из которых принимаются анонсы прогнозов.
+// Пустой список означает «все чаты».
+type SourcesConfig struct {
+	// BaseChannel — основной канал-источник: chat ID, @username или ссылка t.me
+	BaseChannel     string        `yaml:"base_channel" env:"BASE_PREDICT_CH"`
+	ChatIDs         []int64       `yaml:"chat_ids"`
+	Usernames       []string      `yaml:"usernames"`
+	TitlePatterns   []string      `yaml:"title_patterns"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" env-default:"10m"`
+}
+
+// TimeConfig задаёт часовые пояса источника анонсов и целевых каналов
+type TimeConfig struct {
+	SourceTimezone  string           `yaml:"source_timezone" env-default:"Europe/Moscow"`
+	DefaultTimezone string           `yaml:"default_timezone" env-default:"Europe/Moscow"`
+	TargetTimezones map[int64]string `yaml:"target_timezones"`
+	// PublishStarted разрешает публиковать прогнозы на уже начавшиеся матчи
+	PublishStarted bool `yaml:"publish_started"`
+
+	source  *time.Location
+	def     *time.Location
+	targets map[int64]*time.Location
+}
+
+func (t *TimeConfig) resolve() error {
+	var err error
+	if t.source, err = time.LoadLocation(t.SourceTimezone); err != nil {
+		return fmt.Errorf("invalid time.source_timezone: %w", err)
+	}
+	if t.def, err = time.LoadLocation(t.DefaultTimezone); err != nil {
+		return fmt.Errorf("invalid time.default_timezone: %w", err)
+	}
+	t.targets = make(map[int64]*time.Location, len(t.TargetTimezones))
+	for chatID, name := range t.TargetTimezones {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return fmt.Errorf("invalid time.target_timezones[%d]: %w", chatID, err)
+		}
+		t.targets[chatID] = loc
+	}
+	return nil
+}
+
+// SourceLocation — часовой пояс, в котором агрегатор пишет время начала матча
+func (t *TimeConfig) SourceLocation() *time.Location {
+	return t.source
+}
+
+// TargetLocation — часовой пояс для вывода времени в целевом канале
+func (t *TimeConfig) TargetLocation(chatID int64) *time.Location {
+	if loc, ok := t.targets[chatID]; ok {
+		return loc
+	}
+	return t.def
+}
+
+// Empty сообщает, что ни одного источника не задано
+func (s SourcesConfig) Empty() bool {
+	return len(s.ChatIDs) == 0 && len(s.Usernames) == 0 && len(s.TitlePatterns) == 0
+}
+
+// Flags — параметры командной строки
+type Flags struct {
+	// ConfigPath — путь к YAML; пусто — только переменные окружения
+	ConfigPath string
+	// PrintConfig — вывести итоговую конфигурацию без секретов и выйти
+	PrintConfig bool
+}
+
+// ParseFlags читает -config и -print-config. Путь: флаг > CONFIG_PATH.
+func ParseFlags() Flags {
+	var f Flags
+	flag.StringVar(&f.ConfigPath, "config", "", "path to config file")
+	flag.BoolVar(&f.PrintConfig, "print-config", false, "print effective config with secrets redacted and exit")
 	flag.Parse()
 
-	if res == "" {
-		res = os.Getenv("CONFIG_PATH")
+	if f.ConfigPath == "" {
+		f.ConfigPath = os.Getenv("CONFIG_PATH")
 	}
+	return f
+}
 
-	return res
+// Load читает файл configPath (если задан), поверх него — переменные окружения,
+// и проверяет результат. Ошибки проверки возвращаются все сразу вместе с прочитанным
+// конфигом; nil-конфиг означает, что прочитать его не удалось.
+func Load(configPath string) (*Config, error) {
+	var cfg Config
+	if configPath != "" {
+		if _, err := os.Stat(configPath); err != nil {
+			return nil, fmt.Errorf("config file: %w", err)
+		}
+		if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
+			return nil, fmt.Errorf("read config: %w", err)
+		}
+	} else if err := cleanenv.ReadEnv(&cfg); err != nil {
+		return nil, fmt.Errorf("read env: %w", err)
+	}
+
+	if cfg.Sources.BaseChannel != "" {
+		addSource(&cfg.Sources, cfg.Sources.BaseChannel)
+	}
+	if e := cfg.Proxy.Env; e.Server != "" && e.Type != "none" {
+		px := TelegramProxy{Type: e.Type, Server: e.Server, Port: e.Port, User: e.User, Password: e.Password, Secret: e.Secret}
+		cfg.Proxy.Telegram.List = append([]TelegramProxy{px}, cfg.Proxy.Telegram.List...)
+	}
+	return &cfg, cfg.validate()
+}
+
+func (c *Config) validate() error {
+	var errs []error
+	if c.Env != "dev" && c.Env != "prod" {
+		errs = append(errs, fmt.Errorf("env: want dev or prod, got %q", c.Env))
+	}
+	if c.Telegram.APIID <= 0 {
+		errs = append(errs, errors.New("telegram.api_id (TELEGRAM_API_ID) is required"))
+	}
+	if c.Telegram.APIHash == "" {
+		errs = append(errs, errors.New("telegram.api_hash (TELEGRAM_API_HASH) is required"))
+	}
+	if m := c.Telegram.Auth.Method; m != "code" && m != "qr" {
+		errs = append(errs, fmt.Errorf("telegram.auth.method: want code or qr, got %q", m))
+	}
+	if c.Telegram.Auth.FilePollInterval <= 0 {
+		errs = append(errs, fmt.Errorf("telegram.auth.file_poll_interval: must be positive, got %s", c.Telegram.Auth.FilePollInterval))
+	}
+	if err := c.Proxy.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Scraper.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Time.resolve(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Storage.Path == "" {
+		errs = append(errs, errors.New("storage.path is required"))
+	}
+	if !c.Sources.Empty() && c.Sources.RefreshInterval <= 0 {
+		errs = append(errs, fmt.Errorf("sources.refresh_interval: must be positive, got %s", c.Sources.RefreshInterval))
+	}
+	if c.Dedup.TTL <= 0 {
+		errs = append(errs, fmt.Errorf("dedup.ttl: must be positive, got %s", c.Dedup.TTL))
+	}
+	if c.OutcomePoll.InitialDelay <= 0 || c.OutcomePoll.MaxDelay < c.OutcomePoll.InitialDelay {
+		errs = append(errs, fmt.Errorf("outcome_poll: invalid delays %s..%s", c.OutcomePoll.InitialDelay, c.OutcomePoll.MaxDelay))
+	}
+	if err := c.Pipeline.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Routing.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Templates.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (p *PipelineConfig) validate() error {
+	var errs []error
+	if p.ParseWorkers <= 0 || p.FetchWorkers <= 0 || p.FormatWorkers <= 0 {
+		errs = append(errs, errors.New("pipeline: worker counts must be positive"))
+	}
+	if p.QueueSize <= 0 {
+		errs = append(errs, errors.New("pipeline.queue_size: must be positive"))
+	}
+	if p.SendDelayMin < 0 || p.SendDelayMax < p.SendDelayMin {
+		errs = append(errs, fmt.Errorf("pipeline: invalid send delay range %s..%s", p.SendDelayMin, p.SendDelayMax))
+	}
+	return errors.Join(errs...)
+}
+
+func validateURL(raw string) error {
+	if raw == "" {
+		return errors.New("is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("want absolute http(s) URL, got %q", raw)
+	}
+	return nil
+}
+
+// addSource добавляет sources.base_channel в список источников:
+// число трактуется как chat ID, остальное — как username (@name или t.me/name)
+func addSource(s *SourcesConfig, ch string) {
+	ch = strings.TrimSpace(ch)
+	if id, err := strconv.ParseInt(ch, 10, 64); err == nil {
+		s.ChatIDs = append(s.ChatIDs, id)
+		return
+	}
+	ch = strings.TrimPrefix(ch, "https://")
+	ch = strings.TrimPrefix(ch, "t.me/")
+	ch = strings.TrimPrefix(ch, "@")
+	s.Usernames = append(s.Usernames, ch)
 }
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"io"
+	"net/url"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "***"
+
+// Redacted возвращает копию конфига, в которой секреты заменены на ***
+func (c *Config) Redacted() Config {
+	r := *c
+	redact(&r.Telegram.APIHash)
+	redact(&r.Telegram.Auth.Code)
+	redact(&r.Telegram.Auth.Password)
+	redact(&r.Telegram.Auth.AdminToken)
+	redact(&r.Proxy.Env.Password)
+	redact(&r.Proxy.Env.Secret)
+	r.Proxy.Telegram.List = append([]TelegramProxy(nil), c.Proxy.Telegram.List...)
+	for i := range r.Proxy.Telegram.List {
+		redact(&r.Proxy.Telegram.List[i].Password)
+		redact(&r.Proxy.Telegram.List[i].Secret)
+	}
+	r.Proxy.Scraper.URLs = make([]string, len(c.Proxy.Scraper.URLs))
+	for i, raw := range c.Proxy.Scraper.URLs {
+		r.Proxy.Scraper.URLs[i] = redactURL(raw)
+	}
+	return r
+}
+
+func redact(s *string) {
+	if *s != "" {
+		*s = redacted
+	}
+}
+
+// Print выводит итоговую конфигурацию в YAML без секретов
+func (c *Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// redactURL скрывает пароль в user:password@host
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounceDelay — редакторы пишут файл в несколько приёмов; ждём, пока затихнет
+const debounceDelay = 500 * time.Millisecond
+
+// Watcher перечитывает конфигурацию при изменении файла и по SIGHUP.
+// Новый конфиг проверяется целиком и при ошибке отклоняется. На ходу применяются
+// только секции без соединений (см. diff); изменения остальных ждут перезапуска.
+type Watcher struct {
+	logger *slog.Logger
+	path   string
+	apply  func(*Config) error
+
+	// initial — конфиг, с которым стартовали TDLib, хранилище и сервер
+	initial *Config
+	current *Config
+}
+
+// NewWatcher создаёт наблюдателя; apply получает проверенный конфиг
+// и может отклонить его, вернув ошибку
+func NewWatcher(logger *slog.Logger, path string, current *Config, apply func(*Config) error) *Watcher {
+	return &Watcher{
+		logger:  logger,
+		path:    path,
+		apply:   apply,
+		initial: current,
+		current: current,
+	}
+}
+
+// Run следит за файлом и SIGHUP до отмены ctx
+func (w *Watcher) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if w.path != "" {
+		fw, err := fsnotify.NewWatcher()
+		if err != nil {
+			w.logger.Warn("Config file watch unavailable, reload on SIGHUP only", "error", err)
+		} else {
+			defer fw.Close()
+			// Следим за каталогом: редакторы и ConfigMap подменяют файл целиком
+			if err := fw.Add(filepath.Dir(w.path)); err != nil {
+				w.logger.Warn("Config file watch unavailable, reload on SIGHUP only", "path", w.path, "error", err)
+			} else {
+				events, errs = fw.Events, fw.Errors
+			}
+		}
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			w.reload("sighup")
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if w.concerns(ev) {
+				debounce = time.After(debounceDelay)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			w.logger.Warn("Config file watch error", "error", err)
+		case <-debounce:
+			debounce = nil
+			w.reload("file")
+		}
+	}
+}
+
+// concerns — событие относится к файлу конфига или к симлинку ..data у ConfigMap
+func (w *Watcher) concerns(ev fsnotify.Event) bool {
+	if ev.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Base(ev.Name)
+	return name == filepath.Base(w.path) || strings.HasPrefix(name, "..")
+}
+
+func (w *Watcher) reload(trigger string) {
+	next, err := Load(w.path)
+	if err != nil {
+		w.logger.Error("Config reload rejected", "trigger", trigger, "error", err)
+		return
+	}
+
+	applied, _ := diff(w.current, next)
+	_, pending := diff(w.initial, next)
+	if len(applied) == 0 && len(pending) == 0 {
+		w.logger.Debug("Config unchanged", "trigger", trigger)
+		return
+	}
+	if err := w.apply(next); err != nil {
+		w.logger.Error("Config reload rejected", "trigger", trigger, "error", err)
+		return
+	}
+	w.current = next
+	if len(applied) > 0 {
+		w.logger.Info("Config reloaded", "trigger", trigger, "sections", applied)
+	}
+	if len(pending) > 0 {
+		w.logger.Warn("Config changes require restart", "sections", pending)
+	}
+}
+
+// diff сравнивает секции: reloaded применяются на ходу, restart — только после перезапуска
+func diff(old, next *Config) (reloaded, restart []string) {
+	sections := []struct {
+		name      string
+		reload    bool
+		old, next any
+	}{
+		{"routing.rules", true, old.Routing.Rules, next.Routing.Rules},
+		{"routing.catch_all", true, old.Routing.CatchAll, next.Routing.CatchAll},
+		{"routing.sync", true, old.Routing.Sync, next.Routing.Sync},
+		{"pipeline.send_delay", true, sendDelays(old.Pipeline), sendDelays(next.Pipeline)},
+		{"parsers", true, old.Parsers, next.Parsers},
+		{"templates", true, old.Templates, next.Templates},
+
+		{"env", false, old.Env, next.Env},
+		{"telegram", false, old.Telegram, next.Telegram},
+		{"proxy", false, old.Proxy, next.Proxy},
+		{"scraper", false, old.Scraper, next.Scraper},
+		{"sources", false, old.Sources, next.Sources},
+		{"time", false, timeSettings(old.Time), timeSettings(next.Time)},
+		{"storage", false, old.Storage, next.Storage},
+		{"dedup", false, old.Dedup, next.Dedup},
+		{"outcome_poll", false, old.OutcomePoll, next.OutcomePoll},
+		{"pipeline", false, poolSettings(old.Pipeline), poolSettings(next.Pipeline)},
+		{"http", false, old.HTTP, next.HTTP},
+		{"routing.discovery", false, old.Routing.Discovery, next.Routing.Discovery},
+		{"shutdown_timeout", false, old.ShutdownTimeout, next.ShutdownTimeout},
+	}
+	for _, s := range sections {
+		if reflect.DeepEqual(s.old, s.next) {
+			continue
+		}
+		if s.reload {
+			reloaded = append(reloaded, s.name)
+		} else {
+			restart = append(restart, s.name)
+		}
+	}
+	return reloaded, restart
+}
+
+func sendDelays(p PipelineConfig) [2]time.Duration {
+	return [2]time.Duration{p.SendDelayMin, p.SendDelayMax}
+}
+
+// poolSettings — настройки конвейера без пауз, которые применяются на ходу
+func poolSettings(p PipelineConfig) PipelineConfig {
+	p.SendDelayMin, p.SendDelayMax = 0, 0
+	return p
+}
+
+// timeSettings — TimeConfig без вычисленных *time.Location
+func timeSettings(t TimeConfig) TimeConfig {
+	return TimeConfig{
+		SourceTimezone:  t.SourceTimezone,
+		DefaultTimezone: t.DefaultTimezone,
+		TargetTimezones: t.TargetTimezones,
+		PublishStarted:  t.PublishStarted,
+	}
+}
@@ -0,0 +1,105 @@
+package dedup
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+)
+
+var _ ports.DedupStore = (*Store)(nil)
+
+// Истёкшие ключи вычищаются не чаще этого интервала
+const purgeInterval = time.Minute
+
+// Store — TTL-множество ключей в памяти с необязательной персистентностью
+type Store struct {
+	logger  *slog.Logger
+	ttl     time.Duration
+	persist ports.DedupPersister
+
+	mu         sync.Mutex
+	keys       map[string]time.Time
+	lastPurged time.Time
+
+	suppressed atomic.Int64
+}
+
+// New создаёт хранилище; persist может быть nil — тогда ключи живут только в памяти
+func New(logger *slog.Logger, ttl time.Duration, persist ports.DedupPersister) *Store {
+	s := &Store{
+		logger:     logger,
+		ttl:        ttl,
+		persist:    persist,
+		keys:       make(map[string]time.Time),
+		lastPurged: time.Now(),
+	}
+	if persist != nil {
+		keys, err := persist.LoadDedupKeys(time.Now())
+		if err != nil {
+			logger.Error("Load dedup keys failed", "error", err)
+		} else {
+			s.keys = keys
+			logger.Info("Dedup keys loaded", "count", len(keys))
+		}
+	}
+	return s
+}
+
+func (s *Store) Remember(key string) bool {
+	now := time.Now()
+
+	s.mu.Lock()
+	s.purgeLocked(now)
+	if exp, ok := s.keys[key]; ok && exp.After(now) {
+		s.mu.Unlock()
+		s.suppressed.Add(1)
+		return false
+	}
+	expiresAt := now.Add(s.ttl)
+	s.keys[key] = expiresAt
+	s.mu.Unlock()
+
+	if s.persist != nil {
+		if err := s.persist.SaveDedupKey(key, expiresAt); err != nil {
+			s.logger.Error("Persist dedup key failed", "key", key, "error", err)
+		}
+	}
+	return true
+}
+
+func (s *Store) Forget(key string) {
+	s.mu.Lock()
+	delete(s.keys, key)
+	s.mu.Unlock()
+
+	if s.persist != nil {
+		if err := s.persist.DeleteDedupKey(key); err != nil {
+			s.logger.Error("Delete dedup key failed", "key", key, "error", err)
+		}
+	}
+}
+
+// Suppressed — сколько дублей отсечено с момента запуска
+func (s *Store) Suppressed() int64 {
+	return s.suppressed.Load()
+}
+
+func (s *Store) purgeLocked(now time.Time) {
+	if now.Sub(s.lastPurged) < purgeInterval {
+		return
+	}
+	s.lastPurged = now
+	for key, exp := range s.keys {
+		if !exp.After(now) {
+			delete(s.keys, key)
+		}
+	}
+	if s.persist != nil {
+		if err := s.persist.DeleteExpiredDedupKeys(now); err != nil {
+			s.logger.Error("Purge dedup keys failed", "error", err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package dedup
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// memPersister хранит ключи в памяти вместо базы
+type memPersister struct {
+	mu      sync.Mutex
+	keys    map[string]time.Time
+	loadErr error
+	purged  int
+}
+
+func newMemPersister() *memPersister {
+	return &memPersister{keys: make(map[string]time.Time)}
+}
+
+func (p *memPersister) LoadDedupKeys(now time.Time) (map[string]time.Time, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.loadErr != nil {
+		return nil, p.loadErr
+	}
+	res := make(map[string]time.Time)
+	for key, exp := range p.keys {
+		if exp.After(now) {
+			res[key] = exp
+		}
+	}
+	return res, nil
+}
+
+func (p *memPersister) SaveDedupKey(key string, expiresAt time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[key] = expiresAt
+	return nil
+}
+
+func (p *memPersister) DeleteDedupKey(key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.keys, key)
+	return nil
+}
+
+func (p *memPersister) DeleteExpiredDedupKeys(now time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.purged++
+	for key, exp := range p.keys {
+		if !exp.After(now) {
+			delete(p.keys, key)
+		}
+	}
+	return nil
+}
+
+func (p *memPersister) has(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.keys[key]
+	return ok
+}
+
+func TestRememberExpires(t *testing.T) {
+	s := New(testLogger(), 50*time.Millisecond, nil)
+
+	if !s.Remember("a") {
+		t.Fatal("first Remember = false, want true")
+	}
+	if s.Remember("a") {
+		t.Fatal("Remember before ttl = true, want false")
+	}
+	if !s.Remember("b") {
+		t.Fatal("Remember of another key = false, want true")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !s.Remember("a") {
+		t.Error("Remember after ttl = false, want true")
+	}
+	// Повторное запоминание продлевает ключ на полный ttl
+	if s.Remember("a") {
+		t.Error("Remember right after renewal = true, want false")
+	}
+}
+
+func TestForget(t *testing.T) {
+	p := newMemPersister()
+	s := New(testLogger(), time.Hour, p)
+
+	s.Remember("a")
+	s.Forget("a")
+	if p.has("a") {
+		t.Error("forgotten key is still persisted")
+	}
+	if !s.Remember("a") {
+		t.Error("Remember after Forget = false, want true")
+	}
+}
+
+func TestReloadFromPersister(t *testing.T) {
+	p := newMemPersister()
+	p.keys["expired"] = time.Now().Add(-time.Minute)
+
+	s := New(testLogger(), time.Hour, p)
+	s.Remember("a")
+	s.Remember("b")
+	s.Forget("b")
+
+	// Перезапуск: новое хранилище поверх той же базы
+	s = New(testLogger(), time.Hour, p)
+	if s.Remember("a") {
+		t.Error("key remembered before restart was accepted again")
+	}
+	if !s.Remember("b") {
+		t.Error("key forgotten before restart is still taken")
+	}
+	if !s.Remember("expired") {
+		t.Error("key expired before restart is still taken")
+	}
+}
+
+func TestLoadFailureStartsEmpty(t *testing.T) {
+	p := newMemPersister()
+	p.keys["a"] = time.Now().Add(time.Hour)
+	p.loadErr = errors.New("database is locked")
+
+	s := New(testLogger(), time.Hour, p)
+	if !s.Remember("b") {
+		t.Error("Remember after failed load = false, want true")
+	}
+	if !p.has("b") {
+		t.Error("key is not persisted after failed load")
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	p := newMemPersister()
+	s := New(testLogger(), 10*time.Millisecond, p)
+
+	s.Remember("a")
+	time.Sleep(20 * time.Millisecond)
+	s.Remember("b")
+	if p.purged != 0 {
+		t.Fatalf("purged %d times within purge interval, want 0", p.purged)
+	}
+
+	s.mu.Lock()
+	s.lastPurged = time.Now().Add(-purgeInterval)
+	s.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	s.Remember("c")
+
+	if p.purged != 1 {
+		t.Errorf("purged %d times, want 1", p.purged)
+	}
+	s.mu.Lock()
+	_, a := s.keys["a"]
+	_, b := s.keys["b"]
+	s.mu.Unlock()
+	if a || b {
+		t.Errorf("expired keys left in memory: a=%v b=%v", a, b)
+	}
+	if p.has("a") || p.has("b") || !p.has("c") {
+		t.Errorf("persisted keys = %v, want only c", p.keys)
+	}
+}
+
+func TestSuppressed(t *testing.T) {
+	s := New(testLogger(), time.Hour, nil)
+
+	s.Remember("a")
+	s.Remember("b")
+	if n := s.Suppressed(); n != 0 {
+		t.Fatalf("Suppressed = %d, want 0", n)
+	}
+	for i := 0; i < 3; i++ {
+		s.Remember("a")
+	}
+	s.Remember("b")
+	// Снятый ключ снова принимается и дублем не считается
+	s.Forget("b")
+	s.Remember("b")
+
+	if n := s.Suppressed(); n != 4 {
+		t.Errorf("Suppressed = %d, want 4", n)
+	}
+}
@@ -0,0 +1,20 @@
+package domain
+
+// AuthField — данные, которые TDLib запрашивает при входе в аккаунт
+type AuthField string
+
+const (
+	AuthPhone    AuthField = "phone"
+	AuthCode     AuthField = "code"
+	AuthPassword AuthField = "password"
+)
+
+// AuthStatus — ход авторизации Telegram-аккаунта
+type AuthStatus struct {
+	State string `json:"state"`
+	// Waiting — какое поле сейчас нужно ввести; пусто, если ничего не ждём
+	Waiting AuthField `json:"waiting,omitempty"`
+	// QRLink — ссылка tg://login для входа по QR-коду
+	QRLink    string `json:"qr_link,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
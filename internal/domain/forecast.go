@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// AnnouncementKind — тип анонса агрегатора
+type AnnouncementKind string
+
+const (
+	KindNewForecast    AnnouncementKind = "new"
+	KindEditedForecast AnnouncementKind = "edited"
+	KindExpress        AnnouncementKind = "express"
+	KindResult         AnnouncementKind = "result"
+)
+
+// Forecast описывает анонс прогноза, разобранный из сообщения агрегатора
+type Forecast struct {
+	// ID — идентификатор в хранилище, 0 — ещё не сохранён
+	ID   int64
+	Kind AnnouncementKind
+	// Parser — имя формата, которым разобрано сообщение
+	Parser string
+
+	Capper   string
+	Sport    string
+	League   string
+	HomeTeam string
+	AwayTeam string
+	Kickoff  time.Time
+
+	// Coef — коэффициент из анонса; CoefApprox выставлен для записи вида «~2»
+	Coef       decimal.Decimal
+	CoefApprox bool
+	Stake      decimal.Decimal
+
+	// Legs заполняется только для экспрессов
+	Legs []Leg
+	// Result — итог из уведомления о расчёте прогноза
+	Result string
+
+	SourceChatID    int64
+	SourceMessageID int64
+	RawText         string
+	// PhotoFile — фото из исходного сообщения, если анонс пришёл подписью к нему
+	PhotoFile string
+}
+
+// Teams возвращает пару команд в виде «Хозяева - Гости»
+func (f *Forecast) Teams() string {
+	return f.HomeTeam + " - " + f.AwayTeam
+}
+
+// Leg — одно событие экспресса
+type Leg struct {
+	League   string
+	HomeTeam string
+	AwayTeam string
+	Kickoff  time.Time
+}
+
+// Teams возвращает пару команд в виде «Хозяева - Гости»
+func (l *Leg) Teams() string {
+	return l.HomeTeam + " - " + l.AwayTeam
+}
+
+// BetType — вид ставки на сайте каппера
+type BetType string
+
+const (
+	BetSingle  BetType = "single"
+	BetExpress BetType = "express"
+)
+
+// Outcome — ставка, найденная на сайте каппера. Кроме исхода все поля
+// необязательны: сайт может их не показывать.
+type Outcome struct {
+	// Text — исход, например «П1» или «ТБ 2.5»; у экспресса — исходы событий через «; »
+	Text string
+	// Coef — текущий кф на сайте, у экспресса — общий; ноль — не указан
+	Coef    decimal.Decimal
+	Stake   decimal.Decimal
+	BetType BetType
+	League  string
+	// Legs — исходы событий экспресса в порядке Forecast.Legs
+	Legs []LegOutcome
+}
+
+// LegOutcome — исход одного события экспресса на сайте
+type LegOutcome struct {
+	Text string
+	Coef decimal.Decimal
+}
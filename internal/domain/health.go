@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// TelegramStatus — состояние Telegram-клиента для проверок живости и готовности
+type TelegramStatus struct {
+	// AuthState — тип последнего UpdateAuthorizationState, например authorizationStateReady
+	AuthState  string
+	Authorized bool
+	// Listening — горутина чтения обновлений работает
+	Listening   bool
+	LastUpdate  time.Time
+	LastMessage time.Time
+}
@@ -1,9 +1,38 @@
 package domain
 
-// Message описывает входящее сообщение из Telegram
+// MessageEvent — что произошло с сообщением в источнике
+type MessageEvent string
+
+const (
+	MessageNew     MessageEvent = ""
+	MessageEdited  MessageEvent = "edited"
+	MessageDeleted MessageEvent = "deleted"
+)
+
+// Message описывает входящее сообщение из Telegram.
+// Для фото и документов Text — подпись. Фото не скачивается сразу: конвейер
+// загружает его по PhotoFileID, только если подпись разобралась как анонс.
+// У правки — новое содержимое, у удаления заполнены только ID и ChatID.
 type Message struct {
-	ChatID    int64
-	ChatName  string
-	Text      string
+	Event    MessageEvent
+	ID       int64
+	ChatID   int64
+	ChatName string
+	Text     string
+	// PhotoFileID — файл фото в Telegram; 0 — фото нет или оно больше лимита
+	PhotoFileID int32
+}
+
+// OutgoingMessage — сообщение в целевой канал: текст или фото с подписью
+type OutgoingMessage struct {
+	Text FormattedText
+	// PhotoFile — локальный путь к фото; пусто — обычное текстовое сообщение
 	PhotoFile string
 }
+
+// SentMessage — пост анонса, опубликованный в целевом канале
+type SentMessage struct {
+	ForecastID int64
+	ChatID     int64
+	MessageID  int64
+}
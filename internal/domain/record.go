@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+// ForecastStatus — этап обработки анонса
+type ForecastStatus string
+
+const (
+	StatusParsed         ForecastStatus = "parsed"
+	StatusPendingOutcome ForecastStatus = "pending_outcome"
+	StatusOutcomeFound   ForecastStatus = "outcome_found"
+	StatusQueued         ForecastStatus = "queued" // ждёт отправки, после перезапуска отправляется заново
+	StatusSent           ForecastStatus = "sent"
+	StatusSkipped        ForecastStatus = "skipped"
+	StatusDuplicate      ForecastStatus = "duplicate"
+	StatusFailed         ForecastStatus = "failed"
+	StatusDeleted        ForecastStatus = "deleted" // исходное сообщение удалено
+)
+
+// ForecastRecord — анонс вместе с результатом его обработки
+type ForecastRecord struct {
+	Forecast  Forecast
+	Outcome   Outcome
+	Status    ForecastStatus
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PendingLookup — отложенный повторный поиск исхода на сайте каппера
+type PendingLookup struct {
+	ForecastID  int64
+	Attempts    int
+	NextAttempt time.Time
+	// Deadline — после него искать исход бессмысленно (матч начался)
+	Deadline  time.Time
+	LastError string
+}
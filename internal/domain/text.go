@@ -0,0 +1,87 @@
+package domain
+
+import "unicode/utf16"
+
+// Пределы длины текста сообщения и подписи к медиа в Telegram, в UTF-16 code units
+const (
+	MaxMessageLength = 4096
+	MaxCaptionLength = 1024
+)
+
+// TextEntityType — вид оформления фрагмента текста
+type TextEntityType string
+
+const (
+	EntityBold          TextEntityType = "bold"
+	EntityItalic        TextEntityType = "italic"
+	EntityUnderline     TextEntityType = "underline"
+	EntityStrikethrough TextEntityType = "strikethrough"
+	EntitySpoiler       TextEntityType = "spoiler"
+	EntityCode          TextEntityType = "code"
+	EntityPre           TextEntityType = "pre"
+	EntityBlockQuote    TextEntityType = "blockquote"
+	EntityTextURL       TextEntityType = "text_url"
+)
+
+// TextEntity — оформленный фрагмент. Offset и Length, как и в Telegram,
+// считаются в UTF-16 code units: эмодзи вроде 🎯 занимает две единицы.
+type TextEntity struct {
+	Offset int32
+	Length int32
+	Type   TextEntityType
+	// URL — адрес ссылки для EntityTextURL
+	URL string
+	// Language — язык блока EntityPre, может быть пустым
+	Language string
+}
+
+// FormattedText — текст сообщения с оформлением
+type FormattedText struct {
+	Text     string
+	Entities []TextEntity
+}
+
+// PlainText — текст без оформления
+func PlainText(s string) FormattedText {
+	return FormattedText{Text: s}
+}
+
+// UTF16Len — длина строки в UTF-16 code units
+func UTF16Len(s string) int32 {
+	var n int32
+	for _, r := range s {
+		n += int32(utf16.RuneLen(r))
+	}
+	return n
+}
+
+// Truncate обрезает текст до limit UTF-16 code units, не разрывая суррогатные пары;
+// сущности за границей отбрасываются, пересекающие её — укорачиваются
+func (t FormattedText) Truncate(limit int32) FormattedText {
+	if UTF16Len(t.Text) <= limit {
+		return t
+	}
+
+	var n int32
+	cut := len(t.Text)
+	for i, r := range t.Text {
+		w := int32(utf16.RuneLen(r))
+		if n+w > limit {
+			cut = i
+			break
+		}
+		n += w
+	}
+
+	out := FormattedText{Text: t.Text[:cut]}
+	for _, e := range t.Entities {
+		if e.Offset >= n {
+			continue
+		}
+		if e.Offset+e.Length > n {
+			e.Length = n - e.Offset
+		}
+		out.Entities = append(out.Entities, e)
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUTF16Len(t *testing.T) {
+	tests := map[string]int32{
+		"":       0,
+		"abc":    3,
+		"Матч":   4,
+		"🎯":      2,
+		"🎯 Гол!": 7,
+	}
+	for s, want := range tests {
+		if got := UTF16Len(s); got != want {
+			t.Errorf("UTF16Len(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestFormattedTextTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    FormattedText
+		limit int32
+		want  FormattedText
+	}{
+		{
+			name:  "fits",
+			in:    FormattedText{Text: "🎯 ok", Entities: []TextEntity{{Offset: 0, Length: 2, Type: EntityBold}}},
+			limit: 5,
+			want:  FormattedText{Text: "🎯 ok", Entities: []TextEntity{{Offset: 0, Length: 2, Type: EntityBold}}},
+		},
+		{
+			name:  "cyrillic counts one unit per letter",
+			in:    PlainText("Прогноз"),
+			limit: 4,
+			want:  PlainText("Прог"),
+		},
+		{
+			name:  "surrogate pair is not split",
+			in:    PlainText("ab🎯cd"),
+			limit: 3,
+			want:  PlainText("ab"),
+		},
+		{
+			name:  "surrogate pair kept whole at the limit",
+			in:    PlainText("ab🎯cd"),
+			limit: 4,
+			want:  PlainText("ab🎯"),
+		},
+		{
+			name: "entities beyond the cut dropped, crossing ones shortened",
+			in: FormattedText{Text: "🎯 Гол и ещё", Entities: []TextEntity{
+				{Offset: 0, Length: 2, Type: EntityBold},
+				{Offset: 3, Length: 5, Type: EntityItalic},
+				{Offset: 9, Length: 3, Type: EntityTextURL, URL: "https://example.com"},
+			}},
+			limit: 6,
+			want: FormattedText{Text: "🎯 Гол", Entities: []TextEntity{
+				{Offset: 0, Length: 2, Type: EntityBold},
+				{Offset: 3, Length: 3, Type: EntityItalic},
+			}},
+		},
+		{
+			name:  "entity starting at the cut dropped",
+			in:    FormattedText{Text: "abcd", Entities: []TextEntity{{Offset: 2, Length: 2, Type: EntityCode}}},
+			limit: 2,
+			want:  PlainText("ab"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.in.Truncate(tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Truncate(%d) = %+v, want %+v", tt.limit, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package parse
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+)
+
+// expressParser разбирает анонсы экспрессов: после вида спорта идут события
+// по три строки (лига, команды, начало), в конце — общий коэффициент.
+//
+// Каппер - NeNaZavode добавил,
+// Новый экспресс - -
+// Футбол
+// Англия. Премьер-лига
+// Арсенал - Челси,
+// Начало матча 02 ноября 21:00
+// Испания. Ла Лига
+// Барселона - Реал Мадрид,
+// Начало матча 02 ноября 23:00
+// КФ ~3.5, Ставка 400у.е.
+type expressParser struct {
+	markerRe *regexp.Regexp
+	kickoff  *KickoffParser
+}
+
+// NewExpressParser — «Новый экспресс - -»
+func NewExpressParser(k *KickoffParser) ports.AnnouncementParser {
+	return &expressParser{
+		markerRe: regexp.MustCompile(`^Новый экспресс\s*-\s*-$`),
+		kickoff:  k,
+	}
+}
+
+func (p *expressParser) Name() string {
+	return "express"
+}
+
+func (p *expressParser) Parse(text string) (*domain.Forecast, error) {
+	lines := splitLines(text)
+	if len(lines) < 2 || !p.markerRe.MatchString(lines[1]) {
+		return nil, ErrNoMatch
+	}
+	capper, err := parseCapper(lines[0])
+	if err != nil {
+		return nil, ErrNoMatch
+	}
+
+	// каппер, маркер, спорт, минимум два события и строка кф
+	if len(lines) < 3+2*3+1 {
+		return nil, errIncomplete
+	}
+	body := lines[3 : len(lines)-1]
+	if len(body)%3 != 0 {
+		return nil, fmt.Errorf("неполное событие экспресса: %d лишних строк", len(body)%3)
+	}
+
+	f := &domain.Forecast{
+		Kind:   domain.KindExpress,
+		Capper: capper,
+		Sport:  lines[2],
+	}
+	for i := 0; i < len(body); i += 3 {
+		leg := domain.Leg{League: body[i]}
+		if leg.HomeTeam, leg.AwayTeam, err = parseTeams(body[i+1]); err != nil {
+			return nil, err
+		}
+		if leg.Kickoff, err = parseStart(p.kickoff, body[i+2]); err != nil {
+			return nil, err
+		}
+		f.Legs = append(f.Legs, leg)
+	}
+
+	// Верхнеуровневые поля описывают первое по времени событие
+	first := f.Legs[0]
+	for _, leg := range f.Legs[1:] {
+		if leg.Kickoff.Before(first.Kickoff) {
+			first = leg
+		}
+	}
+	f.League, f.HomeTeam, f.AwayTeam, f.Kickoff = first.League, first.HomeTeam, first.AwayTeam, first.Kickoff
+
+	if err = parseCoefLine(f, lines[len(lines)-1]); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,100 @@
+package parse
+
+import (
+	"regexp"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+)
+
+// singleParser разбирает анонсы на одно событие. Форматы отличаются только
+// маркером во второй строке, тело у всех одинаковое:
+//
+// Каппер - NeNaZavode добавил,
+// Новый прогноз - -
+// Футбол
+// Чемпионат Бразилии. Лига Кариока B2
+// Рио-де-Жанейро - Серра Макаенсе,
+// Начало матча 02 ноября 21:00
+// КФ ~2, Ставка 400у.е.
+type singleParser struct {
+	name     string
+	kind     domain.AnnouncementKind
+	markerRe *regexp.Regexp
+	kickoff  *KickoffParser
+}
+
+// NewForecastParser — «Новый прогноз - -»
+func NewForecastParser(k *KickoffParser) ports.AnnouncementParser {
+	return &singleParser{
+		name:     "new_forecast",
+		kind:     domain.KindNewForecast,
+		markerRe: regexp.MustCompile(`^Новый прогноз\s*-\s*-$`),
+		kickoff:  k,
+	}
+}
+
+// NewEditedForecastParser — «Прогноз изменён - -», каппер поправил кф или время
+func NewEditedForecastParser(k *KickoffParser) ports.AnnouncementParser {
+	return &singleParser{
+		name:     "edited_forecast",
+		kind:     domain.KindEditedForecast,
+		markerRe: regexp.MustCompile(`^Прогноз измен[её]н\s*-\s*-$`),
+		kickoff:  k,
+	}
+}
+
+// NewResultParser — «Прогноз рассчитан - Выигрыш», итог уже сыгравшего прогноза
+func NewResultParser(k *KickoffParser) ports.AnnouncementParser {
+	return &singleParser{
+		name:     "result",
+		kind:     domain.KindResult,
+		markerRe: regexp.MustCompile(`^Прогноз рассчитан\s*-\s*(.+)$`),
+		kickoff:  k,
+	}
+}
+
+func (p *singleParser) Name() string {
+	return p.name
+}
+
+func (p *singleParser) Parse(text string) (*domain.Forecast, error) {
+	lines := splitLines(text)
+	if len(lines) < 2 {
+		return nil, ErrNoMatch
+	}
+	marker := p.markerRe.FindStringSubmatch(lines[1])
+	if marker == nil {
+		return nil, ErrNoMatch
+	}
+	capper, err := parseCapper(lines[0])
+	if err != nil {
+		return nil, ErrNoMatch
+	}
+
+	// Формат узнан — дальше ошибки уже содержательные
+	if len(lines) < 7 {
+		return nil, errIncomplete
+	}
+
+	f := &domain.Forecast{
+		Kind:   p.kind,
+		Capper: capper,
+		Sport:  lines[2],
+		League: lines[3],
+	}
+	if len(marker) == 2 {
+		f.Result = marker[1]
+	}
+
+	if f.HomeTeam, f.AwayTeam, err = parseTeams(lines[4]); err != nil {
+		return nil, err
+	}
+	if f.Kickoff, err = parseStart(p.kickoff, lines[5]); err != nil {
+		return nil, err
+	}
+	if err = parseCoefLine(f, lines[6]); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
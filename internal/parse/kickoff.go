@@ -0,0 +1,149 @@
+package parse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var monthsGenitive = []string{
+	"января", "февраля", "марта", "апреля", "мая", "июня",
+	"июля", "августа", "сентября", "октября", "ноября", "декабря",
+}
+
+// monthNames — названия месяцев в родительном и именительном падеже и принятые сокращения
+var monthNames = func() map[string]time.Month {
+	nominative := []string{
+		"январь", "февраль", "март", "апрель", "май", "июнь",
+		"июль", "август", "сентябрь", "октябрь", "ноябрь", "декабрь",
+	}
+	abbrevs := [][]string{
+		{"янв"}, {"фев", "февр"}, {"мар"}, {"апр"}, {}, {"июн"},
+		{"июл"}, {"авг"}, {"сен", "сент"}, {"окт"}, {"ноя", "нояб"}, {"дек"},
+	}
+	names := make(map[string]time.Month)
+	for i := range monthsGenitive {
+		month := time.Month(i + 1)
+		names[monthsGenitive[i]] = month
+		names[nominative[i]] = month
+		for _, a := range abbrevs[i] {
+			names[a] = month
+		}
+	}
+	return names
+}()
+
+var (
+	// "02 ноября 23:30", "2 ноября 2025 в 23:30"
+	absoluteKickoffRe = regexp.MustCompile(`(?i)^(\d{1,2})\s+(\p{L}+)\.?(?:\s+(\d{4}))?(?:\s+в)?\s+(\d{1,2})[:.](\d{2})$`)
+	// "сегодня 23:30", "завтра в 12:00"
+	relativeKickoffRe = regexp.MustCompile(`(?i)^(сегодня|завтра|послезавтра)(?:\s+в)?\s+(\d{1,2})[:.](\d{2})$`)
+)
+
+// KickoffParser разбирает дату начала матча из анонса в часовом поясе источника
+type KickoffParser struct {
+	loc *time.Location
+	now func() time.Time
+}
+
+// NewKickoffParser создаёт парсер дат; loc — часовой пояс, в котором пишет агрегатор
+func NewKickoffParser(loc *time.Location) *KickoffParser {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &KickoffParser{loc: loc, now: time.Now}
+}
+
+// Parse понимает «02 ноября 23:30», «02 ноября 2025 23:30» и «сегодня/завтра/послезавтра 23:30».
+// Для дат без года выбирается ближайший к текущему моменту год;
+// несуществующие даты вроде «31 февраля» отвергаются.
+func (k *KickoffParser) Parse(s string) (time.Time, error) {
+	s = strings.Join(strings.Fields(s), " ")
+	now := k.now().In(k.loc)
+
+	if m := relativeKickoffRe.FindStringSubmatch(s); len(m) == 4 {
+		hour, minute, err := parseClock(m[2], m[3])
+		if err != nil {
+			return time.Time{}, err
+		}
+		days := map[string]int{"сегодня": 0, "завтра": 1, "послезавтра": 2}[strings.ToLower(m[1])]
+		return time.Date(now.Year(), now.Month(), now.Day()+days, hour, minute, 0, 0, k.loc), nil
+	}
+
+	m := absoluteKickoffRe.FindStringSubmatch(s)
+	if len(m) != 6 {
+		return time.Time{}, fmt.Errorf("неверный формат даты: %q", s)
+	}
+	month := parseMonth(m[2])
+	if month == 0 {
+		return time.Time{}, fmt.Errorf("неизвестный месяц: %q", m[2])
+	}
+	day, _ := strconv.Atoi(m[1])
+	hour, minute, err := parseClock(m[4], m[5])
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if m[3] != "" {
+		year, _ := strconv.Atoi(m[3])
+		t, ok := k.date(year, month, day, hour, minute)
+		if !ok {
+			return time.Time{}, fmt.Errorf("несуществующая дата: %q", s)
+		}
+		return t, nil
+	}
+
+	// «02 января» в конце декабря — следующий год, «31 декабря» в начале января — прошлый
+	var (
+		best  time.Time
+		found bool
+	)
+	for year := now.Year() - 1; year <= now.Year()+1; year++ {
+		t, ok := k.date(year, month, day, hour, minute)
+		if ok && (!found || absDuration(t.Sub(now)) < absDuration(best.Sub(now))) {
+			best, found = t, true
+		}
+	}
+	if !found {
+		return time.Time{}, fmt.Errorf("несуществующая дата: %q", s)
+	}
+	return best, nil
+}
+
+// date собирает время; false — такого дня в месяце нет («31 февраля»)
+func (k *KickoffParser) date(year int, month time.Month, day, hour, minute int) (time.Time, bool) {
+	t := time.Date(year, month, day, hour, minute, 0, 0, k.loc)
+	return t, t.Year() == year && t.Month() == month && t.Day() == day
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// parseMonth принимает родительный и именительный падеж, а также сокращения («ноя», «нояб.»);
+// прочие слова с тем же началом («мартини») месяцем не считаются
+func parseMonth(s string) time.Month {
+	return monthNames[strings.ToLower(s)]
+}
+
+func parseClock(h, m string) (int, int, error) {
+	hour, _ := strconv.Atoi(h)
+	minute, _ := strconv.Atoi(m)
+	if hour > 23 || minute > 59 {
+		return 0, 0, fmt.Errorf("некорректное время: %s:%s", h, m)
+	}
+	return hour, minute, nil
+}
+
+// FormatKickoff возвращает дату в виде «02 ноября 23:30» в заданном часовом поясе
+func FormatKickoff(t time.Time, loc *time.Location) string {
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return fmt.Sprintf("%02d %s %02d:%02d", t.Day(), monthsGenitive[t.Month()-1], t.Hour(), t.Minute())
+}
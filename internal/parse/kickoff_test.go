@@ -0,0 +1,90 @@
+package parse
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestKickoffParser(t *testing.T) {
+	msk := mustLoad(t, "Europe/Moscow")
+	yekt := mustLoad(t, "Asia/Yekaterinburg")
+
+	tests := []struct {
+		name string
+		loc  *time.Location
+		now  time.Time
+		in   string
+		// want — время в loc в формате 2006-01-02 15:04; пусто — ожидается ошибка
+		want string
+	}{
+		{name: "same year", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "02 ноября 23:30", want: "2025-11-02 23:30"},
+		{name: "explicit year", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "2 ноября 2026 в 23:30", want: "2026-11-02 23:30"},
+		{name: "extra spaces and dot", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "  2  нояб.  9.05 ", want: "2025-11-02 09:05"},
+		{name: "nominative may", loc: msk, now: time.Date(2025, 4, 20, 12, 0, 0, 0, msk), in: "1 май 10:00", want: "2025-05-01 10:00"},
+		{name: "nominative month", loc: msk, now: time.Date(2025, 8, 20, 12, 0, 0, 0, msk), in: "5 Сентябрь 19:00", want: "2025-09-05 19:00"},
+		{name: "short month", loc: msk, now: time.Date(2025, 2, 20, 12, 0, 0, 0, msk), in: "02 Мар 12:00", want: "2025-03-02 12:00"},
+		{name: "four letter abbreviation", loc: msk, now: time.Date(2025, 8, 20, 12, 0, 0, 0, msk), in: "2 сент. 18:00", want: "2025-09-02 18:00"},
+		{name: "rollover to next year", loc: msk, now: time.Date(2025, 12, 30, 12, 0, 0, 0, msk), in: "02 января 12:00", want: "2026-01-02 12:00"},
+		{name: "rollover to previous year", loc: msk, now: time.Date(2026, 1, 2, 1, 0, 0, 0, msk), in: "31 декабря 20:00", want: "2025-12-31 20:00"},
+		{name: "leap day next year", loc: msk, now: time.Date(2027, 12, 1, 12, 0, 0, 0, msk), in: "29 февраля 18:00", want: "2028-02-29 18:00"},
+		{name: "today", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "сегодня 23:30", want: "2025-10-20 23:30"},
+		{name: "tomorrow across month", loc: msk, now: time.Date(2025, 10, 31, 12, 0, 0, 0, msk), in: "Завтра в 12:00", want: "2025-11-01 12:00"},
+		{name: "day after tomorrow across year", loc: msk, now: time.Date(2025, 12, 31, 12, 0, 0, 0, msk), in: "послезавтра 00:15", want: "2026-01-02 00:15"},
+		// 22:30 UTC 20 октября — в Москве уже 21-е
+		{name: "today in source zone", loc: msk, now: time.Date(2025, 10, 20, 22, 30, 0, 0, time.UTC), in: "сегодня 20:00", want: "2025-10-21 20:00"},
+		{name: "other source zone", loc: yekt, now: time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC), in: "02 ноября 23:30", want: "2025-11-02 23:30"},
+
+		{name: "february 31", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "31 февраля 12:00"},
+		{name: "april 31 with year", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "31 апреля 2026 12:00"},
+		{name: "leap day without leap year nearby", loc: msk, now: time.Date(2026, 10, 20, 12, 0, 0, 0, msk), in: "29 февраля 18:00"},
+		{name: "day zero", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "00 ноября 12:00"},
+		{name: "day 32", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "32 ноября 12:00"},
+		{name: "hour 24", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "02 ноября 24:00"},
+		{name: "minute 60", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "завтра 12:60"},
+		{name: "unknown month", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "02 брумера 12:00"},
+		{name: "word starting like march", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "02 мартини 12:00"},
+		{name: "word starting like may", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "02 майка 12:00"},
+		{name: "word starting like december", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "02 декабрист 12:00"},
+		{name: "truncated month", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "02 ноябр 12:00"},
+		{name: "no time", loc: msk, now: time.Date(2025, 10, 20, 12, 0, 0, 0, msk), in: "завтра"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := NewKickoffParser(tt.loc)
+			k.now = func() time.Time { return tt.now }
+
+			got, err := k.Parse(tt.in)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %v, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.in, err)
+			}
+			if got.Location() != tt.loc {
+				t.Errorf("location = %v, want %v", got.Location(), tt.loc)
+			}
+			if s := got.In(tt.loc).Format("2006-01-02 15:04"); s != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.in, s, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatKickoff(t *testing.T) {
+	kickoff := time.Date(2025, 11, 2, 20, 30, 0, 0, time.UTC)
+	if got, want := FormatKickoff(kickoff, mustLoad(t, "Europe/Moscow")), "02 ноября 23:30"; got != want {
+		t.Errorf("FormatKickoff = %q, want %q", got, want)
+	}
+}
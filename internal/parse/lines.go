@@ -0,0 +1,129 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+var errIncomplete = errors.New("неполное сообщение")
+
+// fieldError привязывает ошибку к строке анонса, в которой она найдена
+type fieldError struct {
+	field string
+	err   error
+}
+
+func (e *fieldError) Error() string { return e.err.Error() }
+func (e *fieldError) Unwrap() error { return e.err }
+
+var (
+	// "Каппер - NeNaZavode добавил,"
+	capperLineRe = regexp.MustCompile(`^Каппер\s*-\s*([^\s,]+)(?:\s+(?:добавил|изменил|рассчитал))?[,;]?\s*$`)
+	// "Рио-де-Жанейро - Серра Макаенсе,"
+	teamsLineRe = regexp.MustCompile(`^\s*.+\s-\s.+,\s*$`)
+	// "Начало матча 02 ноября 23:30"
+	startLineRe = regexp.MustCompile(`(?i)^Начало\s+матча\s+(.+)$`)
+	// "КФ ~2, Ставка 400у.е."
+	coefRe  = regexp.MustCompile(`~?\s*\d+(?:[.,]\d+)?`)
+	stakeRe = regexp.MustCompile(`(?i)Ставка\s*(\d+(?:[.,]\d+)?)`)
+)
+
+// splitLines нормализует переносы и возвращает непустые строки без краевых пробелов
+func splitLines(message string) []string {
+	msg := strings.ReplaceAll(message, "\r\n", "\n")
+	msg = strings.ReplaceAll(msg, "\r", "\n")
+
+	lines := []string{}
+	for _, l := range strings.Split(msg, "\n") {
+		trim := strings.TrimSpace(l)
+		if trim != "" {
+			lines = append(lines, trim)
+		}
+	}
+	return lines
+}
+
+func parseCapper(line string) (string, error) {
+	m := capperLineRe.FindStringSubmatch(line)
+	if len(m) != 2 {
+		return "", errors.New("неверная строка каппера")
+	}
+	return m[1], nil
+}
+
+// parseTeams разбирает строку «Хозяева - Гости,»
+func parseTeams(line string) (string, string, error) {
+	if !teamsLineRe.MatchString(line) {
+		return "", "", &fieldError{"teams", fmt.Errorf("некорректная строка команд: %s", line)}
+	}
+	home, away := SplitTeams(strings.TrimRight(line, ", "))
+	if home == "" || away == "" {
+		return "", "", &fieldError{"teams", fmt.Errorf("не удалось разделить команды: %s", line)}
+	}
+	return home, away, nil
+}
+
+func parseStart(k *KickoffParser, line string) (time.Time, error) {
+	m := startLineRe.FindStringSubmatch(line)
+	if len(m) != 2 {
+		return time.Time{}, &fieldError{"kickoff", errors.New("неверная строка даты")}
+	}
+	t, err := k.Parse(m[1])
+	if err != nil {
+		return time.Time{}, &fieldError{"kickoff", err}
+	}
+	return t, nil
+}
+
+// parseCoefLine заполняет коэффициент и ставку из строки «КФ ~2, Ставка 400у.е.»
+func parseCoefLine(f *domain.Forecast, line string) error {
+	coef := coefRe.FindString(line)
+	if coef == "" {
+		return &fieldError{"coef", errors.New("не найден коэффициент")}
+	}
+	var err error
+	f.Coef, f.CoefApprox, err = ParseCoef(coef)
+	if err != nil {
+		return &fieldError{"coef", err}
+	}
+	if m := stakeRe.FindStringSubmatch(line); len(m) == 2 {
+		f.Stake, _ = decimal.NewFromString(strings.ReplaceAll(m[1], ",", "."))
+	}
+	return nil
+}
+
+// ParseCoef разбирает коэффициент вида «~2», «1.85», «1,85»
+func ParseCoef(s string) (decimal.Decimal, bool, error) {
+	s = strings.TrimSpace(s)
+	approx := strings.HasPrefix(s, "~")
+	s = strings.TrimSpace(strings.TrimPrefix(s, "~"))
+	coef, err := decimal.NewFromString(strings.ReplaceAll(s, ",", "."))
+	if err != nil {
+		return decimal.Decimal{}, false, fmt.Errorf("некорректный коэффициент %q: %w", s, err)
+	}
+	return coef, approx, nil
+}
+
+// SplitTeams делит «Хозяева - Гости» на две команды
+func SplitTeams(teams string) (string, string) {
+	t := strings.ReplaceAll(teams, "—", "-")
+	t = strings.ReplaceAll(t, "–", "-")
+	t = strings.ReplaceAll(t, "−", "-")
+	// Сначала разделитель с пробелами: в названиях бывают дефисы (Рио-де-Жанейро)
+	if a, b, ok := strings.Cut(t, " - "); ok {
+		return strings.TrimSpace(a), strings.TrimSpace(b)
+	}
+	parts := strings.Split(t, "-")
+	if len(parts) >= 2 {
+		a := strings.TrimSpace(parts[0])
+		b := strings.TrimSpace(strings.Join(parts[1:], "-"))
+		return a, b
+	}
+	return strings.TrimSpace(teams), ""
+}
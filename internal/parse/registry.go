@@ -0,0 +1,118 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+)
+
+// ErrNoMatch возвращается парсером, если сообщение не относится к его формату
+var ErrNoMatch = errors.New("сообщение не соответствует формату")
+
+// ErrDisabled — сообщение узнано форматом, выключенным в parsers.disabled
+var ErrDisabled = errors.New("формат отключён")
+
+// Error — ошибка формата, узнавшего сообщение
+type Error struct {
+	Parser string
+	Err    error
+}
+
+func (e *Error) Error() string { return e.Parser + ": " + e.Err.Error() }
+func (e *Error) Unwrap() error { return e.Err }
+
+// FailureReason раскладывает ошибку Parse на имя парсера и короткую причину
+// для метрик: unknown_format, disabled, incomplete, teams, kickoff, coef или invalid
+func FailureReason(err error) (parser, reason string) {
+	var pe *Error
+	if errors.As(err, &pe) {
+		parser = pe.Parser
+	}
+	var fe *fieldError
+	switch {
+	case errors.Is(err, ErrNoMatch):
+		return parser, "unknown_format"
+	case errors.Is(err, ErrDisabled):
+		return parser, "disabled"
+	case errors.Is(err, errIncomplete):
+		return parser, "incomplete"
+	case errors.As(err, &fe):
+		return parser, fe.field
+	default:
+		return parser, "invalid"
+	}
+}
+
+// Registry перебирает зарегистрированные форматы анонсов по порядку
+type Registry struct {
+	parsers  []ports.AnnouncementParser
+	disabled atomic.Pointer[map[string]bool]
+}
+
+// NewRegistry создаёт реестр из заданных парсеров
+func NewRegistry(parsers ...ports.AnnouncementParser) *Registry {
+	return &Registry{parsers: parsers}
+}
+
+// NewDefaultRegistry создаёт реестр со всеми встроенными форматами
+func NewDefaultRegistry(k *KickoffParser) *Registry {
+	return NewRegistry(
+		NewForecastParser(k),
+		NewEditedForecastParser(k),
+		NewExpressParser(k),
+		NewResultParser(k),
+	)
+}
+
+// Register добавляет формат в конец списка
+func (r *Registry) Register(p ports.AnnouncementParser) {
+	r.parsers = append(r.parsers, p)
+}
+
+// SetDisabled выключает форматы по имени, остальные включает.
+// Безопасно вызывать на ходу; неизвестное имя — ошибка, состояние не меняется.
+func (r *Registry) SetDisabled(names []string) error {
+	known := make(map[string]bool, len(r.parsers))
+	for _, p := range r.parsers {
+		known[p.Name()] = true
+	}
+	disabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("неизвестный формат %q в parsers.disabled", name)
+		}
+		disabled[name] = true
+	}
+	r.disabled.Store(&disabled)
+	return nil
+}
+
+// Parse пробует форматы по очереди и заполняет Forecast.Parser именем сработавшего.
+// Ошибка формата, узнавшего сообщение, возвращается сразу.
+// Выключенный формат по-прежнему узнаёт свои сообщения и возвращает ErrDisabled,
+// чтобы они не считались неизвестными.
+func (r *Registry) Parse(text string) (*domain.Forecast, error) {
+	var disabled map[string]bool
+	if d := r.disabled.Load(); d != nil {
+		disabled = *d
+	}
+	for _, p := range r.parsers {
+		f, err := p.Parse(text)
+		if errors.Is(err, ErrNoMatch) {
+			continue
+		}
+		if disabled[p.Name()] {
+			return nil, &Error{Parser: p.Name(), Err: ErrDisabled}
+		}
+		if err != nil {
+			return nil, &Error{Parser: p.Name(), Err: err}
+		}
+		f.Parser = p.Name()
+		f.RawText = text
+		return f, nil
+	}
+	return nil, fmt.Errorf("неизвестный формат сообщения: %w", ErrNoMatch)
+}
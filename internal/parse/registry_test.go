@@ -0,0 +1,169 @@
+package parse
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func testRegistry(t *testing.T) *Registry {
+	t.Helper()
+	k := NewKickoffParser(mustLoad(t, "Europe/Moscow"))
+	k.now = func() time.Time { return time.Date(2025, 10, 20, 12, 0, 0, 0, k.loc) }
+	return NewDefaultRegistry(k)
+}
+
+func TestRegistryParse(t *testing.T) {
+	r := testRegistry(t)
+	msk := mustLoad(t, "Europe/Moscow")
+
+	tests := []struct {
+		name   string
+		text   string
+		parser string
+		want   domain.Forecast
+	}{
+		{
+			name: "new forecast",
+			text: "Каппер - NeNaZavode добавил,\nНовый прогноз - -\nФутбол\nЧемпионат Бразилии. Лига Кариока B2\n" +
+				"Рио-де-Жанейро - Серра Макаенсе,\nНачало матча 02 ноября 21:00\nКФ ~2, Ставка 400у.е.",
+			parser: "new_forecast",
+			want: domain.Forecast{
+				Kind: domain.KindNewForecast, Capper: "NeNaZavode", Sport: "Футбол", League: "Чемпионат Бразилии. Лига Кариока B2",
+				HomeTeam: "Рио-де-Жанейро", AwayTeam: "Серра Макаенсе", Kickoff: time.Date(2025, 11, 2, 21, 0, 0, 0, msk),
+				Coef: decimal.NewFromInt(2), CoefApprox: true, Stake: decimal.NewFromInt(400),
+			},
+		},
+		{
+			name:   "edited forecast, CRLF and blank lines",
+			text:   "Каппер - Tester изменил,\r\nПрогноз изменён - -\r\n\r\nТеннис\r\nATP. Вена\r\nМедведев Д. - Рублёв А.,\r\nНачало матча завтра в 15:30\r\nКФ 1,85",
+			parser: "edited_forecast",
+			want: domain.Forecast{
+				Kind: domain.KindEditedForecast, Capper: "Tester", Sport: "Теннис", League: "ATP. Вена",
+				HomeTeam: "Медведев Д.", AwayTeam: "Рублёв А.", Kickoff: time.Date(2025, 10, 21, 15, 30, 0, 0, msk),
+				Coef: decimal.RequireFromString("1.85"),
+			},
+		},
+		{
+			name:   "result",
+			text:   "Каппер - Tester рассчитал,\nПрогноз рассчитан - Выигрыш\nФутбол\nАПЛ\nАрсенал - Челси,\nНачало матча 19 октября 19:30\nКФ 1.9",
+			parser: "result",
+			want: domain.Forecast{
+				Kind: domain.KindResult, Capper: "Tester", Sport: "Футбол", League: "АПЛ", Result: "Выигрыш",
+				HomeTeam: "Арсенал", AwayTeam: "Челси", Kickoff: time.Date(2025, 10, 19, 19, 30, 0, 0, msk),
+				Coef: decimal.RequireFromString("1.9"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := r.Parse(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Parser != tt.parser || f.RawText != tt.text {
+				t.Errorf("parser = %q, raw text kept = %v", f.Parser, f.RawText == tt.text)
+			}
+			got := *f
+			got.Parser, got.RawText = "", ""
+			if !forecastEqual(got, tt.want) {
+				t.Errorf("forecast = %+v\nwant       %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryParseExpress(t *testing.T) {
+	r := testRegistry(t)
+	text := "Каппер - NeNaZavode добавил,\nНовый экспресс - -\nФутбол\n" +
+		"Испания. Ла Лига\nБарселона - Реал Мадрид,\nНачало матча 02 ноября 23:00\n" +
+		"Англия. Премьер-лига\nАрсенал - Челси,\nНачало матча 02 ноября 21:00\n" +
+		"КФ ~3.5, Ставка 400у.е."
+	f, err := r.Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Parser != "express" || f.Kind != domain.KindExpress || len(f.Legs) != 2 {
+		t.Fatalf("parser = %q, kind = %q, legs = %d", f.Parser, f.Kind, len(f.Legs))
+	}
+	if f.Legs[0].Teams() != "Барселона - Реал Мадрид" || f.Legs[1].League != "Англия. Премьер-лига" {
+		t.Errorf("legs = %+v", f.Legs)
+	}
+	// верхнеуровневые поля — первое по времени событие
+	if f.Teams() != "Арсенал - Челси" || f.League != "Англия. Премьер-лига" || f.Kickoff.Hour() != 21 {
+		t.Errorf("first event = %s, %s, %s", f.Teams(), f.League, f.Kickoff)
+	}
+	if !f.Coef.Equal(decimal.RequireFromString("3.5")) || !f.CoefApprox {
+		t.Errorf("coef = %s approx %v", f.Coef, f.CoefApprox)
+	}
+}
+
+func TestRegistryParseErrors(t *testing.T) {
+	const header = "Каппер - Tester добавил,\nНовый прогноз - -\n"
+	tests := []struct {
+		name     string
+		text     string
+		disabled []string
+		parser   string
+		reason   string
+	}{
+		{name: "unknown format", text: "Привет", reason: "unknown_format"},
+		{name: "edited express is not a format", text: "Каппер - Tester изменил,\nЭкспресс изменён - -\nФутбол", reason: "unknown_format"},
+		{name: "incomplete", text: header + "Футбол\nАПЛ", parser: "new_forecast", reason: "incomplete"},
+		{name: "teams", text: header + "Футбол\nАПЛ\nАрсенал Челси\nНачало матча 02 ноября 21:00\nКФ 2", parser: "new_forecast", reason: "teams"},
+		{name: "kickoff", text: header + "Футбол\nАПЛ\nАрсенал - Челси,\nНачало матча 02 брумера 21:00\nКФ 2", parser: "new_forecast", reason: "kickoff"},
+		{name: "coef", text: header + "Футбол\nАПЛ\nАрсенал - Челси,\nНачало матча 02 ноября 21:00\nКФ нет", parser: "new_forecast", reason: "coef"},
+		{name: "express leg count", text: "Каппер - Tester добавил,\nНовый экспресс - -\nФутбол\nАПЛ\nАрсенал - Челси,\nНачало матча 02 ноября 21:00\nЛа Лига\nБарселона - Реал,\nНачало матча 02 ноября 23:00\nСерия А\nКФ 3", parser: "express", reason: "invalid"},
+		{
+			name:     "disabled",
+			text:     header + "Футбол\nАПЛ\nАрсенал - Челси,\nНачало матча 02 ноября 21:00\nКФ 2",
+			disabled: []string{"new_forecast"},
+			parser:   "new_forecast",
+			reason:   "disabled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRegistry(t)
+			if err := r.SetDisabled(tt.disabled); err != nil {
+				t.Fatal(err)
+			}
+			_, err := r.Parse(tt.text)
+			if err == nil {
+				t.Fatal("want error")
+			}
+			parser, reason := FailureReason(err)
+			if parser != tt.parser || reason != tt.reason {
+				t.Errorf("FailureReason = %q, %q; want %q, %q (%v)", parser, reason, tt.parser, tt.reason, err)
+			}
+			if tt.reason == "disabled" && !errors.Is(err, ErrDisabled) {
+				t.Errorf("err = %v, want ErrDisabled", err)
+			}
+		})
+	}
+}
+
+func TestRegistrySetDisabledUnknown(t *testing.T) {
+	r := testRegistry(t)
+	if err := r.SetDisabled([]string{"new_forecast"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetDisabled([]string{"nope"}); err == nil {
+		t.Fatal("want error for unknown format")
+	}
+	// неудачный вызов состояние не меняет
+	_, err := r.Parse("Каппер - Tester добавил,\nНовый прогноз - -\nФутбол\nАПЛ\nАрсенал - Челси,\nНачало матча 02 ноября 21:00\nКФ 2")
+	if !errors.Is(err, ErrDisabled) {
+		t.Errorf("err = %v, want ErrDisabled", err)
+	}
+}
+
+func forecastEqual(a, b domain.Forecast) bool {
+	return a.Kind == b.Kind && a.Capper == b.Capper && a.Sport == b.Sport && a.League == b.League &&
+		a.HomeTeam == b.HomeTeam && a.AwayTeam == b.AwayTeam && a.Kickoff.Equal(b.Kickoff) &&
+		a.Coef.Equal(b.Coef) && a.CoefApprox == b.CoefApprox && a.Stake.Equal(b.Stake) &&
+		a.Result == b.Result && len(a.Legs) == len(b.Legs)
+}
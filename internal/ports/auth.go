@@ -0,0 +1,10 @@
+package ports
+
+import "github.com/larriantoniy/tg_pipe_bot/internal/domain"
+
+// Authenticator принимает данные для входа в Telegram-аккаунт без терминала
+type Authenticator interface {
+	AuthStatus() domain.AuthStatus
+	// SubmitAuth передаёт телефон, код или пароль 2FA ожидающей авторизации
+	SubmitAuth(field domain.AuthField, value string) error
+}
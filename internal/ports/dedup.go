@@ -0,0 +1,19 @@
+package ports
+
+import "time"
+
+// DedupStore помнит уже обработанные анонсы в течение TTL
+type DedupStore interface {
+	// Remember запоминает ключ; false означает, что ключ уже встречался и ещё не истёк
+	Remember(key string) bool
+	// Forget снимает ключ, чтобы повторная доставка анонса могла быть обработана
+	Forget(key string)
+}
+
+// DedupPersister сохраняет ключи дедупликации между перезапусками
+type DedupPersister interface {
+	LoadDedupKeys(now time.Time) (map[string]time.Time, error)
+	SaveDedupKey(key string, expiresAt time.Time) error
+	DeleteDedupKey(key string) error
+	DeleteExpiredDedupKeys(now time.Time) error
+}
@@ -0,0 +1,52 @@
+package ports
+
+import (
+	"context"
+	"errors"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+)
+
+// ForecastParser разбирает входящее сообщение в анонс прогноза
+type ForecastParser interface {
+	ParseForecast(msg domain.Message) (*domain.Forecast, error)
+}
+
+// OutcomeFetcher находит исход ставки на сайте каппера
+type OutcomeFetcher interface {
+	FetchOutcome(ctx context.Context, f *domain.Forecast) (domain.Outcome, error)
+}
+
+// ErrBetNotFound — на странице каппера ещё нет ставки на матч из анонса
+var ErrBetNotFound = errors.New("ставка не найдена")
+
+// ErrExpressUnsupported — у сайта не заданы селекторы экспресса, искать экспрессы негде
+var ErrExpressUnsupported = errors.New("экспрессы на сайте не поддерживаются")
+
+// OutcomeProvider ищет исход ставки на одном сайте капперов.
+// Если ставки на странице ещё нет, ошибка оборачивает ErrBetNotFound.
+type OutcomeProvider interface {
+	Name() string
+	FindOutcome(ctx context.Context, f *domain.Forecast) (domain.Outcome, error)
+}
+
+// ForecastFormatter формирует сообщение для целевого канала
+type ForecastFormatter interface {
+	FormatForecast(f *domain.Forecast, o domain.Outcome, chatID int64) (domain.OutgoingMessage, error)
+}
+
+// TextParser превращает разметку (markdown или html) в текст с сущностями;
+// пустой mode — текст как есть
+type TextParser interface {
+	ParseText(text, mode string) (domain.FormattedText, error)
+}
+
+// MessageSender отправляет готовое сообщение (текст или фото с подписью) в чат
+// и возвращает ID отправленного сообщения
+type MessageSender interface {
+	SendMessage(ctx context.Context, chatID int64, msg domain.OutgoingMessage) (int64, error)
+	// EditMessage заменяет текст (или подпись к фото) ранее отправленного сообщения
+	EditMessage(ctx context.Context, chatID, messageID int64, msg domain.OutgoingMessage) error
+	// DeleteMessages удаляет сообщения у всех участников чата
+	DeleteMessages(ctx context.Context, chatID int64, messageIDs []int64) error
+}
@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+)
+
+// TelegramStatusProvider отдаёт текущее состояние Telegram-клиента
+type TelegramStatusProvider interface {
+	Status() domain.TelegramStatus
+}
+
+// SiteChecker проверяет, что сайт капперов отвечает
+type SiteChecker interface {
+	CheckSite(ctx context.Context) error
+}
@@ -0,0 +1,16 @@
+package ports
+
+import "time"
+
+// Metrics собирает счётчики работы бота
+type Metrics interface {
+	MessageReceived(chatID int64)
+	// ParseFailed: reason — короткая причина (unknown_format, incomplete, teams, ...)
+	ParseFailed(parser, reason string)
+	// ScrapeObserved: status — HTTP-код ответа сайта каппера или "error"
+	ScrapeObserved(status string, d time.Duration)
+	// CacheLookup: result — hit, miss, revalidated (304 от сайта) или coalesced (ждал чужой запрос)
+	CacheLookup(result string)
+	MessageSent(chatID int64)
+	SendFailed(chatID int64)
+}
@@ -0,0 +1,10 @@
+package ports
+
+import "github.com/larriantoniy/tg_pipe_bot/internal/domain"
+
+// AnnouncementParser разбирает текст анонса одного формата агрегатора.
+// Если сообщение не относится к формату, Parse возвращает parse.ErrNoMatch.
+type AnnouncementParser interface {
+	Name() string
+	Parse(text string) (*domain.Forecast, error)
+}
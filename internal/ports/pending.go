@@ -0,0 +1,10 @@
+package ports
+
+import "github.com/larriantoniy/tg_pipe_bot/internal/domain"
+
+// PendingStore хранит отложенные поиски исхода, чтобы они переживали перезапуск
+type PendingStore interface {
+	SavePending(l domain.PendingLookup) error
+	DeletePending(forecastID int64) error
+	ListPending() ([]domain.PendingLookup, error)
+}
@@ -0,0 +1,7 @@
+package ports
+
+// ChannelDirectory знает целевые каналы капперов
+type ChannelDirectory interface {
+	// TargetChannel возвращает канал каппера; false — канала нет или в нём нельзя публиковать
+	TargetChannel(capper string) (int64, bool)
+}
@@ -0,0 +1,29 @@
+package ports
+
+import (
+	"errors"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+)
+
+// ErrNotFound — запись не найдена
+var ErrNotFound = errors.New("not found")
+
+// ForecastStore хранит историю обработанных анонсов
+type ForecastStore interface {
+	// SaveForecast сохраняет разобранный анонс и возвращает его ID
+	SaveForecast(f *domain.Forecast) (int64, error)
+	// UpdateForecast перезаписывает разобранные поля после правки исходного сообщения
+	UpdateForecast(f *domain.Forecast) error
+	SetOutcome(id int64, o domain.Outcome) error
+	// MarkSent запоминает пост в целевом канале для последующих правок и удалений;
+	// статус анонса не меняет
+	MarkSent(id, targetChatID, sentMessageID int64) error
+	ListSent(id int64) ([]domain.SentMessage, error)
+	SetStatus(id int64, status domain.ForecastStatus, reason string) error
+	GetForecast(id int64) (*domain.ForecastRecord, error)
+	// FindBySource возвращает последний анонс из сообщения источника;
+	// если такого нет — ошибку, оборачивающую ErrNotFound
+	FindBySource(chatID, messageID int64) (*domain.ForecastRecord, error)
+	ListByStatus(status domain.ForecastStatus, limit int) ([]domain.ForecastRecord, error)
+}
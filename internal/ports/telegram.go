@@ -1,6 +1,8 @@
 package ports
 
 import (
+	"context"
+
 	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
 	"github.com/zelenin/go-tdlib/client"
 )
@@ -8,9 +10,18 @@ import (
 // TelegramClient определяет интерфейс для работы с Telegram
 // Реализуется конкретными адаптерами (TDLib, Bot API и т.д.).
 type TelegramClient interface {
-	// Listen возвращает канал доменных сообщений
-	Listen() (<-chan domain.Message, error)
+	// Listen возвращает канал доменных сообщений; канал закрывается при отмене ctx
+	Listen(ctx context.Context) (<-chan domain.Message, error)
 	ProcessUpdateNewMessage(out chan domain.Message, upd *client.UpdateNewMessage) (<-chan domain.Message, error)
-	GetAdminChannelsSimple() (map[string]string, error)
-	SendMessage(chatID int64, text string) error
+	ChannelDirectory
+	MessageSender
+	FileDownloader
+	TelegramStatusProvider
+	// Close завершает сессию и дожидается, пока клиент сбросит базу на диск
+	Close(ctx context.Context) error
+}
+
+// FileDownloader скачивает вложение входящего сообщения и возвращает локальный путь
+type FileDownloader interface {
+	DownloadFile(ctx context.Context, fileID int32) (string, error)
 }
@@ -0,0 +1,77 @@
+package prediction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+)
+
+var _ ports.OutcomeFetcher = (*OutcomeChain)(nil)
+
+// OutcomeChain опрашивает провайдеров по порядку, пока один не найдёт исход.
+// Для каппера из scraper.cappers используется его собственный порядок.
+type OutcomeChain struct {
+	logger    *slog.Logger
+	providers []ports.OutcomeProvider
+	byCapper  map[string][]ports.OutcomeProvider
+}
+
+// NewOutcomeChain собирает цепочку; cappers — каппер → имена провайдеров.
+// Неизвестные имена пропускаются (конфиг их уже отверг).
+func NewOutcomeChain(logger *slog.Logger, providers []ports.OutcomeProvider, cappers map[string][]string) *OutcomeChain {
+	byName := make(map[string]ports.OutcomeProvider, len(providers))
+	for _, pr := range providers {
+		byName[pr.Name()] = pr
+	}
+	c := &OutcomeChain{
+		logger:    logger,
+		providers: providers,
+		byCapper:  make(map[string][]ports.OutcomeProvider, len(cappers)),
+	}
+	for capper, names := range cappers {
+		var order []ports.OutcomeProvider
+		for _, name := range names {
+			if pr, ok := byName[name]; ok {
+				order = append(order, pr)
+			}
+		}
+		c.byCapper[strings.ToLower(capper)] = order
+	}
+	return c
+}
+
+// FetchOutcome возвращает исход от первого провайдера, который его нашёл,
+// иначе — ошибки всех провайдеров вместе
+func (c *OutcomeChain) FetchOutcome(ctx context.Context, f *domain.Forecast) (domain.Outcome, error) {
+	providers, ok := c.byCapper[strings.ToLower(f.Capper)]
+	if !ok {
+		providers = c.providers
+	}
+	if len(providers) == 0 {
+		return domain.Outcome{}, fmt.Errorf("нет сайтов для каппера %q", f.Capper)
+	}
+
+	var errs []error
+	for _, pr := range providers {
+		o, err := pr.FindOutcome(ctx, f)
+		if err == nil {
+			return o, nil
+		}
+		if ctx.Err() != nil {
+			return domain.Outcome{}, ctx.Err()
+		}
+		if len(providers) > 1 {
+			c.logger.Debug("Outcome provider failed", "provider", pr.Name(), "capper", f.Capper, "teams", f.Teams(), "error", err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", pr.Name(), err))
+	}
+	if len(errs) == 1 {
+		return domain.Outcome{}, errors.Unwrap(errs[0])
+	}
+	return domain.Outcome{}, errors.Join(errs...)
+}
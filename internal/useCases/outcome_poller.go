@@ -0,0 +1,175 @@
+package prediction
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+)
+
+// outcomePoller перезапрашивает страницу каппера, пока на ней не появится ставка
+// или не начнётся матч. Сайт отстаёт от анонса на секунды-минуты.
+type outcomePoller struct {
+	logger  *slog.Logger
+	cfg     config.OutcomePollConfig
+	fetcher ports.OutcomeFetcher
+	pending ports.PendingStore
+
+	onFound  func(f *domain.Forecast, o domain.Outcome)
+	onFailed func(f *domain.Forecast, lastErr error)
+
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// start задаёт контекст, отмена которого останавливает все поиски
+func (p *outcomePoller) start(ctx context.Context) {
+	p.ctx, p.cancel = context.WithCancel(ctx)
+}
+
+// stop прерывает поиски и ждёт их завершения; они остаются в хранилище до следующего запуска
+func (p *outcomePoller) stop() {
+	p.mu.Lock()
+	p.cancel()
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+func (p *outcomePoller) spawn(f *domain.Forecast, l domain.PendingLookup) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ctx.Err() != nil {
+		return
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run(f, l)
+	}()
+}
+
+// Schedule ставит анонс в очередь повторного поиска после первой неудачи
+func (p *outcomePoller) Schedule(f *domain.Forecast, firstErr error) {
+	p.enqueue(f, 1, time.Now().Add(p.cfg.InitialDelay), firstErr)
+}
+
+// Lookup ищет исход заново без начальной паузы: прежний поиск шёл по устаревшему анонсу.
+// После stop поиск только сохраняется и продолжится после перезапуска.
+func (p *outcomePoller) Lookup(f *domain.Forecast, reason error) {
+	p.enqueue(f, 0, time.Now(), reason)
+}
+
+func (p *outcomePoller) enqueue(f *domain.Forecast, attempts int, next time.Time, reason error) {
+	l := domain.PendingLookup{
+		ForecastID:  f.ID,
+		Attempts:    attempts,
+		NextAttempt: next,
+		Deadline:    f.Kickoff,
+		LastError:   reason.Error(),
+	}
+	p.save(l)
+	p.spawn(f, l)
+}
+
+// Resume поднимает отложенные поиски, сохранённые до перезапуска
+func (p *outcomePoller) Resume(store ports.ForecastStore) {
+	lookups, err := p.pending.ListPending()
+	if err != nil {
+		p.logger.Error("Load pending lookups failed", "error", err)
+		return
+	}
+	for _, l := range lookups {
+		rec, err := store.GetForecast(l.ForecastID)
+		if err != nil {
+			p.logger.Error("Load pending forecast failed", "id", l.ForecastID, "error", err)
+			p.delete(l.ForecastID)
+			continue
+		}
+		f := rec.Forecast
+		p.spawn(&f, l)
+	}
+	if len(lookups) > 0 {
+		p.logger.Info("Pending outcome lookups resumed", "count", len(lookups))
+	}
+}
+
+func (p *outcomePoller) run(f *domain.Forecast, l domain.PendingLookup) {
+	for {
+		if wait := time.Until(l.NextAttempt); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-p.ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+
+		outcome, err := p.fetcher.FetchOutcome(p.ctx, f)
+		if p.ctx.Err() != nil {
+			// Останов: поиск продолжится после перезапуска
+			return
+		}
+		if err == nil {
+			p.delete(f.ID)
+			p.logger.Info("Outcome found after retry", "id", f.ID, "capper", f.Capper, "teams", f.Teams(), "attempts", l.Attempts+1)
+			p.onFound(f, outcome)
+			return
+		}
+
+		l.Attempts++
+		l.LastError = err.Error()
+		if !retryable(err) {
+			p.delete(f.ID)
+			p.logger.Error("Outcome lookup failed", "id", f.ID, "capper", f.Capper, "teams", f.Teams(), "attempts", l.Attempts, "error", err)
+			p.onFailed(f, err)
+			return
+		}
+		l.NextAttempt = time.Now().Add(p.backoff(l.Attempts))
+		if !l.NextAttempt.Before(l.Deadline) {
+			p.delete(f.ID)
+			p.logger.Warn("Outcome lookup expired at kickoff", "id", f.ID, "capper", f.Capper, "teams", f.Teams(), "attempts", l.Attempts, "error", err)
+			p.onFailed(f, err)
+			return
+		}
+		p.save(l)
+		p.logger.Debug("Outcome not found yet, retry scheduled", "id", f.ID, "attempt", l.Attempts, "next_attempt", l.NextAttempt, "error", err)
+	}
+}
+
+// backoff — экспоненциальная задержка от InitialDelay до MaxDelay
+func (p *outcomePoller) backoff(attempts int) time.Duration {
+	d := p.cfg.InitialDelay
+	for i := 1; i < attempts && d < p.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.cfg.MaxDelay {
+		d = p.cfg.MaxDelay
+	}
+	return d
+}
+
+// Без ID (хранилище недоступно) поиск живёт только в памяти
+func (p *outcomePoller) save(l domain.PendingLookup) {
+	if l.ForecastID == 0 {
+		return
+	}
+	if err := p.pending.SavePending(l); err != nil {
+		p.logger.Error("Save pending lookup failed", "id", l.ForecastID, "error", err)
+	}
+}
+
+func (p *outcomePoller) delete(forecastID int64) {
+	if forecastID == 0 {
+		return
+	}
+	if err := p.pending.DeletePending(forecastID); err != nil {
+		p.logger.Error("Delete pending lookup failed", "id", forecastID, "error", err)
+	}
+}
@@ -0,0 +1,264 @@
+package prediction
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+)
+
+// scriptedFetcher отвечает «ставки ещё нет» первые notFound раз, затем находит исход
+type scriptedFetcher struct {
+	mu       sync.Mutex
+	notFound int
+	err      error
+	calls    []time.Time
+}
+
+func (s *scriptedFetcher) FetchOutcome(ctx context.Context, f *domain.Forecast) (domain.Outcome, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, time.Now())
+	if s.err != nil {
+		return domain.Outcome{}, s.err
+	}
+	if len(s.calls) <= s.notFound {
+		return domain.Outcome{}, ErrBetNotFound
+	}
+	return domain.Outcome{Text: "П1"}, nil
+}
+
+func (s *scriptedFetcher) callTimes() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time(nil), s.calls...)
+}
+
+// memPending запоминает сохранённые поиски и момент сохранения
+type memPending struct {
+	mu      sync.Mutex
+	lookups map[int64]domain.PendingLookup
+	saves   []savedLookup
+}
+
+type savedLookup struct {
+	lookup domain.PendingLookup
+	at     time.Time
+}
+
+func (m *memPending) SavePending(l domain.PendingLookup) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lookups == nil {
+		m.lookups = make(map[int64]domain.PendingLookup)
+	}
+	m.lookups[l.ForecastID] = l
+	m.saves = append(m.saves, savedLookup{lookup: l, at: time.Now()})
+	return nil
+}
+
+func (m *memPending) DeletePending(forecastID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.lookups, forecastID)
+	return nil
+}
+
+func (m *memPending) ListPending() ([]domain.PendingLookup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []domain.PendingLookup
+	for _, l := range m.lookups {
+		res = append(res, l)
+	}
+	return res, nil
+}
+
+func (m *memPending) savedLookups() []savedLookup {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]savedLookup(nil), m.saves...)
+}
+
+// pollResult — чем закончился поиск: исходом или ошибкой
+type pollResult struct {
+	outcome domain.Outcome
+	err     error
+}
+
+func newTestPoller(fetcher *scriptedFetcher, initial, max time.Duration) (*outcomePoller, *memPending, chan pollResult) {
+	pending := &memPending{}
+	done := make(chan pollResult, 1)
+	p := &outcomePoller{
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		cfg:      config.OutcomePollConfig{InitialDelay: initial, MaxDelay: max},
+		fetcher:  fetcher,
+		pending:  pending,
+		onFound:  func(f *domain.Forecast, o domain.Outcome) { done <- pollResult{outcome: o} },
+		onFailed: func(f *domain.Forecast, err error) { done <- pollResult{err: err} },
+	}
+	p.start(context.Background())
+	return p, pending, done
+}
+
+func pollForecast(kickoffIn time.Duration) *domain.Forecast {
+	return &domain.Forecast{ID: 1, Capper: "Tester", HomeTeam: "Арсенал", AwayTeam: "Челси", Kickoff: time.Now().Add(kickoffIn)}
+}
+
+func waitResult(t *testing.T, done <-chan pollResult) pollResult {
+	t.Helper()
+	select {
+	case r := <-done:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("lookup did not finish")
+		return pollResult{}
+	}
+}
+
+func TestPollerBackoff(t *testing.T) {
+	p := &outcomePoller{cfg: config.OutcomePollConfig{InitialDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}}
+	want := []time.Duration{10, 20, 40, 50, 50}
+	for i, w := range want {
+		if got := p.backoff(i + 1); got != w*time.Millisecond {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, w*time.Millisecond)
+		}
+	}
+}
+
+// Паузы между попытками удваиваются, а сохранённая следующая попытка не дальше MaxDelay
+func TestPollerRetriesUntilFound(t *testing.T) {
+	const initial, max = 10 * time.Millisecond, 30 * time.Millisecond
+	fetcher := &scriptedFetcher{notFound: 3}
+	p, pending, done := newTestPoller(fetcher, initial, max)
+	defer p.stop()
+
+	start := time.Now()
+	p.Schedule(pollForecast(time.Hour), ErrBetNotFound)
+	r := waitResult(t, done)
+	if r.err != nil || r.outcome.Text != "П1" {
+		t.Fatalf("result = %+v, want outcome П1", r)
+	}
+
+	calls := fetcher.callTimes()
+	if len(calls) != 4 {
+		t.Fatalf("fetched %d times, want 4", len(calls))
+	}
+	prev := start
+	for i, at := range calls {
+		if gap, want := at.Sub(prev), p.backoff(i+1); gap < want {
+			t.Errorf("attempt %d came after %s, want at least %s", i+1, gap, want)
+		}
+		prev = at
+	}
+
+	saves := pending.savedLookups()
+	if len(saves) != 4 {
+		t.Fatalf("saved %d times, want the first lookup and 3 retries", len(saves))
+	}
+	for i, s := range saves {
+		if s.lookup.Attempts != i+1 {
+			t.Errorf("save %d: attempts = %d, want %d", i, s.lookup.Attempts, i+1)
+		}
+		if wait := s.lookup.NextAttempt.Sub(s.at); wait > max {
+			t.Errorf("save %d: next attempt in %s, want at most %s", i, wait, max)
+		}
+	}
+	if left, _ := pending.ListPending(); len(left) != 0 {
+		t.Errorf("pending after success = %+v, want none", left)
+	}
+}
+
+func TestPollerStopsAtKickoff(t *testing.T) {
+	fetcher := &scriptedFetcher{notFound: 1000}
+	p, pending, done := newTestPoller(fetcher, 20*time.Millisecond, time.Second)
+	defer p.stop()
+
+	f := pollForecast(100 * time.Millisecond)
+	p.Schedule(f, ErrBetNotFound)
+	r := waitResult(t, done)
+	if !errors.Is(r.err, ErrBetNotFound) {
+		t.Fatalf("err = %v, want ErrBetNotFound", r.err)
+	}
+	calls := fetcher.callTimes()
+	if len(calls) == 0 || !calls[len(calls)-1].Before(f.Kickoff) {
+		t.Errorf("attempts %v, want all before kickoff %v", calls, f.Kickoff)
+	}
+	if left, _ := pending.ListPending(); len(left) != 0 {
+		t.Errorf("pending after kickoff = %+v, want none", left)
+	}
+}
+
+func TestPollerGivesUpOnPermanentError(t *testing.T) {
+	fetcher := &scriptedFetcher{err: errors.New("страница каппера не найдена")}
+	p, pending, done := newTestPoller(fetcher, time.Millisecond, time.Second)
+	defer p.stop()
+
+	p.Schedule(pollForecast(time.Hour), ErrBetNotFound)
+	r := waitResult(t, done)
+	if r.err != fetcher.err {
+		t.Fatalf("err = %v, want %v", r.err, fetcher.err)
+	}
+	if n := len(fetcher.callTimes()); n != 1 {
+		t.Errorf("fetched %d times, want 1", n)
+	}
+	if left, _ := pending.ListPending(); len(left) != 0 {
+		t.Errorf("pending after failure = %+v, want none", left)
+	}
+}
+
+// Поиск, ждущий паузы, прерывается остановом и остаётся в хранилище
+func TestPollerStopKeepsPending(t *testing.T) {
+	fetcher := &scriptedFetcher{}
+	p, pending, _ := newTestPoller(fetcher, time.Hour, time.Hour)
+
+	p.Schedule(pollForecast(2*time.Hour), ErrBetNotFound)
+	stopped := make(chan struct{})
+	go func() {
+		p.stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop did not interrupt the wait")
+	}
+
+	if n := len(fetcher.callTimes()); n != 0 {
+		t.Errorf("fetched %d times, want 0", n)
+	}
+	if left, _ := pending.ListPending(); len(left) != 1 {
+		t.Errorf("pending after stop = %+v, want the scheduled lookup", left)
+	}
+}
+
+// После останова новые поиски не запускаются, а только сохраняются до перезапуска
+func TestPollerSpawnAfterStop(t *testing.T) {
+	fetcher := &scriptedFetcher{}
+	p, pending, done := newTestPoller(fetcher, time.Millisecond, time.Second)
+	p.stop()
+
+	p.Schedule(pollForecast(time.Hour), ErrBetNotFound)
+	f := pollForecast(time.Hour)
+	f.ID = 2
+	p.Lookup(f, errors.New("forecast edited during outcome lookup"))
+	p.wg.Wait()
+
+	select {
+	case r := <-done:
+		t.Fatalf("lookup ran after stop: %+v", r)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if n := len(fetcher.callTimes()); n != 0 {
+		t.Errorf("fetched %d times after stop, want 0", n)
+	}
+	if left, _ := pending.ListPending(); len(left) != 2 {
+		t.Errorf("pending after stop = %+v, want both lookups", left)
+	}
+}
@@ -0,0 +1,699 @@
+package prediction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/parse"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+)
+
+var _ ports.MessageProc = (*Pipeline)(nil)
+
+// Сколько недоотправленных анонсов поднимать из хранилища при старте
+const resumeLimit = 1000
+
+// PipelineDeps — зависимости Pipeline
+type PipelineDeps struct {
+	Service *PredictionService
+	Store   ports.ForecastStore
+	Pending ports.PendingStore
+	Dedup   ports.DedupStore
+	Sender  ports.MessageSender
+	Metrics ports.Metrics
+	// Files скачивает фото анонсов, пришедших подписью к картинке
+	Files ports.FileDownloader
+	// Channels — каналы капперов, найденные по названию
+	Channels ports.ChannelDirectory
+}
+
+// job — анонс, проходящий по этапам конвейера
+type job struct {
+	forecast *domain.Forecast
+	outcome  domain.Outcome
+	chatID   int64
+	message  domain.OutgoingMessage
+	delivery *delivery
+}
+
+// delivery — рассылка анонса по каналам. Итоговый статус ставится, когда
+// обработаны все каналы: если пост есть хотя бы в одном, анонс считается отправленным.
+type delivery struct {
+	mu        sync.Mutex
+	remaining int
+	// sent — число постов, включая отправленные до перезапуска
+	sent    int
+	failed  error
+	skipped error
+	flushed bool
+	dropped bool
+}
+
+// Результат отправки анонса в один канал
+type targetResult int
+
+const (
+	targetSent targetResult = iota
+	targetFailed
+	targetSkipped // матч начался до отправки
+	targetFlushed // останов до отправки
+	targetDropped // исходное сообщение удалено
+)
+
+// Pipeline проводит входящие сообщения через этапы
+// разбор → поиск исхода → форматирование → отложенная отправка.
+// У каждого этапа свой пул воркеров; анонсы одного источника и одного каппера
+// обрабатываются по порядку, отправка в один канал — последовательно.
+type Pipeline struct {
+	logger   *slog.Logger
+	ps       *PredictionService
+	store    ports.ForecastStore
+	dedup    ports.DedupStore
+	sender   ports.MessageSender
+	files    ports.FileDownloader
+	metrics  ports.Metrics
+	channels ports.ChannelDirectory
+	router   atomic.Pointer[Router]
+	// discovery — настройки поиска каналов на момент запуска: TDLib ищет каналы
+	// только если discovery был включён при старте
+	discovery      config.DiscoveryConfig
+	publishStarted bool
+
+	// ctx — рабочий контекст запросов к сайту и Telegram, отменяется при аварийном останове
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	poller *outcomePoller
+	parse  *stage[domain.Message]
+	fetch  *stage[*job]
+	format *stage[*job]
+	send   *sendScheduler
+}
+
+func NewPipeline(logger *slog.Logger, cfg *config.Config, deps PipelineDeps) *Pipeline {
+	pc := cfg.Pipeline
+	p := &Pipeline{
+		logger:         logger,
+		ps:             deps.Service,
+		store:          deps.Store,
+		dedup:          deps.Dedup,
+		sender:         deps.Sender,
+		files:          deps.Files,
+		metrics:        deps.Metrics,
+		channels:       deps.Channels,
+		discovery:      cfg.Routing.Discovery,
+		publishStarted: cfg.Time.PublishStarted,
+	}
+	p.router.Store(NewRouter(cfg.Routing, deps.Channels))
+	p.parse = newStage(pc.ParseWorkers, pc.QueueSize, p.parseStage)
+	p.fetch = newStage(pc.FetchWorkers, pc.QueueSize, p.fetchStage)
+	p.format = newStage(pc.FormatWorkers, pc.QueueSize, p.formatStage)
+	p.send = newSendScheduler(pc.QueueSize, pc.SendDelayMin, pc.SendDelayMax, p.sendStage, p.flushStage)
+	p.poller = &outcomePoller{
+		logger:  logger,
+		cfg:     cfg.OutcomePoll,
+		fetcher: deps.Service,
+		pending: deps.Pending,
+		onFound: func(f *domain.Forecast, o domain.Outcome) {
+			p.format.submit(f.Capper, &job{forecast: f, outcome: o})
+		},
+		onFailed: func(f *domain.Forecast, err error) {
+			if !p.sourceDeleted(f) && !p.skipUnsupported(f, err) {
+				p.fail(f, err)
+			}
+		},
+	}
+	return p
+}
+
+// Start запускает воркеры и возобновляет работу, прерванную перезапуском:
+// отложенные поиски исходов и подготовленные, но не отправленные прогнозы.
+func (p *Pipeline) Start(ctx context.Context) {
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	p.poller.start(p.ctx)
+
+	p.parse.start()
+	p.fetch.start()
+	p.format.start()
+
+	p.poller.Resume(p.store)
+	p.resumeUnsent()
+}
+
+// Stop дожидается обработки уже принятых сообщений. Паузы перед отправкой
+// прерываются, неотправленное остаётся в хранилище со статусом queued.
+// Если ctx истёк раньше, текущие запросы отменяются.
+// Process после Stop вызывать нельзя.
+func (p *Pipeline) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Сначала поллер: его находки уходят в format, который ещё открыт
+		p.poller.stop()
+		p.parse.stop()
+		p.fetch.stop()
+		p.format.stop()
+		p.send.stop()
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		err = fmt.Errorf("pipeline drain interrupted: %w", ctx.Err())
+	}
+	p.cancel()
+	return err
+}
+
+// Reload применяет на ходу маршрутизацию и паузы перед отправкой.
+// Анонсы, уже стоящие в очереди отправки, уходят по прежним маршрутам.
+// routing.discovery меняется только перезапуском.
+func (p *Pipeline) Reload(cfg *config.Config) {
+	routing := cfg.Routing
+	routing.Discovery = p.discovery
+	p.router.Store(NewRouter(routing, p.channels))
+	p.send.setDelays(cfg.Pipeline.SendDelayMin, cfg.Pipeline.SendDelayMax)
+}
+
+// resumeUnsent заново ставит в очередь прогнозы, которые были готовы, но не отправлены.
+// Каналы, куда анонс уже ушёл до перезапуска, formatStage пропускает.
+func (p *Pipeline) resumeUnsent() {
+	var count int
+	for _, status := range []domain.ForecastStatus{domain.StatusOutcomeFound, domain.StatusQueued} {
+		recs, err := p.store.ListByStatus(status, resumeLimit)
+		if err != nil {
+			p.logger.Error("Load unsent forecasts failed", "status", status, "error", err)
+			continue
+		}
+		for i := range recs {
+			f := recs[i].Forecast
+			p.format.submit(f.Capper, &job{forecast: &f, outcome: recs[i].Outcome})
+			count++
+		}
+	}
+	if count > 0 {
+		p.logger.Info("Unsent forecasts resumed", "count", count)
+	}
+}
+
+// Process ставит сообщение в очередь разбора
+func (p *Pipeline) Process(msg domain.Message) error {
+	p.parse.submit(strconv.FormatInt(msg.ChatID, 10), msg)
+	return nil
+}
+
+// parseStage: разбор, запись в историю, фильтры и дедупликация.
+// Правки и удаления идут через тот же этап, чтобы не обогнать сам анонс.
+func (p *Pipeline) parseStage(msg domain.Message) {
+	switch msg.Event {
+	case domain.MessageEdited:
+		p.onEdited(msg)
+		return
+	case domain.MessageDeleted:
+		p.onDeleted(msg)
+		return
+	}
+
+	f, ok := p.parseMessage(msg)
+	if !ok {
+		return
+	}
+	p.save(f)
+	p.admit(f)
+}
+
+// parseMessage разбирает сообщение и скачивает фото анонса;
+// неудача разбора уже залогирована и учтена в метриках
+func (p *Pipeline) parseMessage(msg domain.Message) (*domain.Forecast, bool) {
+	f, err := p.ps.ParseForecast(msg)
+	if err != nil {
+		parser, reason := parse.FailureReason(err)
+		p.metrics.ParseFailed(parser, reason)
+		if errors.Is(err, parse.ErrDisabled) {
+			p.logger.Info("Message format disabled, skipped", "chat_id", msg.ChatID, "parser", parser)
+			return nil, false
+		}
+		p.logger.Error("Parse forecast failed", "chat_id", msg.ChatID, "reason", reason, "text", msg.Text, "error", err)
+		return nil, false
+	}
+	if msg.PhotoFileID != 0 && p.files != nil {
+		path, err := p.files.DownloadFile(p.ctx, msg.PhotoFileID)
+		if err != nil {
+			// Анонс публикуется и без фото
+			p.logger.Error("Photo download failed", "chat_id", msg.ChatID, "message_id", msg.ID, "error", err)
+		}
+		f.PhotoFile = path
+	}
+	return f, true
+}
+
+func (p *Pipeline) save(f *domain.Forecast) {
+	if _, err := p.store.SaveForecast(f); err != nil {
+		// История вторична: без неё прогноз всё равно публикуем
+		p.logger.Error("Save forecast failed", "capper", f.Capper, "error", err)
+	}
+	p.logger.Info("Forecast parsed", "id", f.ID, "parser", f.Parser, "capper", f.Capper, "teams", f.Teams(), "kickoff", f.Kickoff, "coef", f.Coef)
+}
+
+// admit проверяет анонс фильтрами и дедупликацией и отправляет на поиск исхода
+func (p *Pipeline) admit(f *domain.Forecast) {
+	if p.check(f) {
+		p.fetch.submit(f.Capper, &job{forecast: f})
+	}
+}
+
+// check пропускает анонс через фильтры и запоминает его ключ дедупликации.
+// false — анонс отсеян, статус уже выставлен.
+func (p *Pipeline) check(f *domain.Forecast) bool {
+	if err := p.ps.CheckForecast(f); err != nil {
+		p.logger.Info("Forecast skipped", "id", f.ID, "reason", err)
+		p.setStatus(f, domain.StatusSkipped, err)
+		return false
+	}
+
+	key := DedupKey(f)
+	if !p.dedup.Remember(key) {
+		p.logger.Warn("Duplicate announcement suppressed", "id", f.ID, "key", key, "source_chat_id", f.SourceChatID, "source_message_id", f.SourceMessageID)
+		p.setStatus(f, domain.StatusDuplicate, fmt.Errorf("duplicate of %s", key))
+		return false
+	}
+	return true
+}
+
+// onEdited обрабатывает правку исходного сообщения. Анонс обновляется в истории,
+// а уже опубликованные посты — в целевых каналах, если маршрут разрешает, с прежним
+// исходом. Анонс, ещё находящийся в работе, берёт правку из истории перед
+// форматированием и отправкой. Сообщение, которое раньше не разобралось или
+// не прошло фильтры, проходит конвейер заново.
+func (p *Pipeline) onEdited(msg domain.Message) {
+	rec, err := p.store.FindBySource(msg.ChatID, msg.ID)
+	if err != nil && !errors.Is(err, ports.ErrNotFound) {
+		p.logger.Error("Find forecast by source failed", "chat_id", msg.ChatID, "message_id", msg.ID, "error", err)
+		return
+	}
+	f, ok := p.parseMessage(msg)
+	if !ok {
+		return
+	}
+	if rec == nil {
+		p.save(f)
+		p.admit(f)
+		return
+	}
+
+	f.ID = rec.Forecast.ID
+	if f.PhotoFile == "" {
+		f.PhotoFile = rec.Forecast.PhotoFile
+	}
+	if err := p.store.UpdateForecast(f); err != nil {
+		p.logger.Error("Update edited forecast failed", "id", f.ID, "error", err)
+	}
+	p.logger.Info("Forecast updated from edited message", "id", f.ID, "status", rec.Status, "capper", f.Capper, "teams", f.Teams())
+
+	switch rec.Status {
+	case domain.StatusSkipped, domain.StatusFailed:
+		// Ключ мог остаться занятым, если анонс отсеяла маршрутизация
+		p.dedup.Forget(DedupKey(&rec.Forecast))
+		p.admit(f)
+	case domain.StatusDeleted:
+	default:
+		// Посты могут быть и у анонса, часть каналов которого ещё ждёт отправки
+		p.propagateEdit(rec, f)
+	}
+}
+
+// propagateEdit перерисовывает опубликованные посты анонса
+func (p *Pipeline) propagateEdit(rec *domain.ForecastRecord, f *domain.Forecast) {
+	sent, err := p.store.ListSent(f.ID)
+	if err != nil {
+		p.logger.Error("Load sent messages failed", "id", f.ID, "error", err)
+		return
+	}
+	router := p.router.Load()
+	for _, m := range sent {
+		if !router.Sync(&rec.Forecast, m.ChatID).Edits {
+			continue
+		}
+		msg, err := p.ps.FormatForecast(f, rec.Outcome, m.ChatID)
+		if err != nil {
+			p.logger.Error("Format edited forecast failed", "id", f.ID, "chat_id", m.ChatID, "error", err)
+			continue
+		}
+		if err := p.sender.EditMessage(p.ctx, m.ChatID, m.MessageID, msg); err != nil {
+			p.logger.Error("Edit published forecast failed", "id", f.ID, "chat_id", m.ChatID, "message_id", m.MessageID, "error", err)
+		}
+	}
+}
+
+// onDeleted помечает анонс удалённым: неопубликованный больше не отправится,
+// а опубликованные посты удаляются там, где это разрешает маршрут
+func (p *Pipeline) onDeleted(msg domain.Message) {
+	rec, err := p.store.FindBySource(msg.ChatID, msg.ID)
+	if errors.Is(err, ports.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		p.logger.Error("Find forecast by source failed", "chat_id", msg.ChatID, "message_id", msg.ID, "error", err)
+		return
+	}
+	f := &rec.Forecast
+	p.setStatus(f, domain.StatusDeleted, errors.New("source message deleted"))
+
+	// Решает наличие постов, а не статус: часть каналов могла ещё ждать отправки
+	sent, err := p.store.ListSent(f.ID)
+	if err != nil {
+		p.logger.Error("Load sent messages failed", "id", f.ID, "error", err)
+		return
+	}
+	if len(sent) == 0 {
+		p.logger.Info("Source message deleted before publication", "id", f.ID, "status", rec.Status)
+		return
+	}
+	router := p.router.Load()
+	byChat := make(map[int64][]int64)
+	for _, m := range sent {
+		if router.Sync(f, m.ChatID).Deletes {
+			byChat[m.ChatID] = append(byChat[m.ChatID], m.MessageID)
+		}
+	}
+	for chatID, ids := range byChat {
+		if err := p.sender.DeleteMessages(p.ctx, chatID, ids); err != nil {
+			p.logger.Error("Delete published forecast failed", "id", f.ID, "chat_id", chatID, "error", err)
+		}
+	}
+	p.logger.Info("Source message deleted", "id", f.ID, "published", len(sent))
+}
+
+// sourceDeleted — исходное сообщение удалено, публиковать анонс больше не нужно
+func (p *Pipeline) sourceDeleted(f *domain.Forecast) bool {
+	_, ok := p.current(f)
+	return !ok
+}
+
+// current возвращает анонс в том виде, в каком он сейчас в истории: исходное
+// сообщение могли отредактировать, пока анонс ждал исхода или отправки.
+// false — исходное сообщение удалено.
+func (p *Pipeline) current(f *domain.Forecast) (*domain.Forecast, bool) {
+	if f.ID == 0 {
+		return f, true
+	}
+	rec, err := p.store.GetForecast(f.ID)
+	if err != nil {
+		p.logger.Error("Load forecast failed", "id", f.ID, "error", err)
+		return f, true
+	}
+	if rec.Status == domain.StatusDeleted {
+		return nil, false
+	}
+	return &rec.Forecast, true
+}
+
+// edited — анонс изменился после того, как по нему подготовили сообщение
+func edited(prev, cur *domain.Forecast) bool {
+	return cur.RawText != prev.RawText || cur.PhotoFile != prev.PhotoFile
+}
+
+// fetchStage: поиск исхода на сайте каппера. Если ставки ещё нет или сайт
+// временно недоступен — отложенный повтор, при остальных ошибках анонс не публикуется.
+func (p *Pipeline) fetchStage(j *job) {
+	f := j.forecast
+	outcome, err := p.ps.FetchOutcome(p.ctx, f)
+	if err != nil {
+		// Отмена — останов: поиск продолжится после перезапуска
+		if (retryable(err) && time.Now().Before(f.Kickoff)) || errors.Is(err, context.Canceled) {
+			// Сайт ещё не показал ставку — перезапросим позже
+			p.setStatus(f, domain.StatusPendingOutcome, err)
+			p.poller.Schedule(f, err)
+			p.logger.Info("Outcome not found yet, lookup scheduled", "id", f.ID, "capper", f.Capper, "teams", f.Teams(), "error", err)
+			return
+		}
+		if p.skipUnsupported(f, err) {
+			return
+		}
+		p.logger.Error("Fetch outcome failed", "id", f.ID, "capper", f.Capper, "teams", f.Teams(), "error", err)
+		p.fail(f, err)
+		return
+	}
+	j.outcome = outcome
+	p.format.submit(f.Capper, j)
+}
+
+// skipUnsupported пропускает экспресс, если сайту не заданы селекторы экспресса:
+// это не сбой поиска, а анонс, который пока нечем обработать. Ключ освобождается,
+// чтобы после настройки селекторов повтор анонса прошёл.
+func (p *Pipeline) skipUnsupported(f *domain.Forecast, err error) bool {
+	if !errors.Is(err, ErrExpressUnsupported) {
+		return false
+	}
+	p.logger.Warn("Express forecast skipped, express selectors are not configured", "id", f.ID, "capper", f.Capper, "legs", len(f.Legs), "error", err)
+	p.setStatus(f, domain.StatusSkipped, err)
+	p.dedup.Forget(DedupKey(f))
+	return true
+}
+
+// formatStage: выбор каналов и подготовка текста для каждого
+func (p *Pipeline) formatStage(j *job) {
+	f, ok := p.current(j.forecast)
+	if !ok {
+		p.logger.Info("Forecast dropped, source message deleted", "id", j.forecast.ID)
+		return
+	}
+	if DedupKey(f) != DedupKey(j.forecast) {
+		p.relookup(j.forecast, f)
+		return
+	}
+	if f.ID != 0 {
+		if err := p.store.SetOutcome(f.ID, j.outcome); err != nil {
+			p.logger.Error("Save outcome failed", "id", f.ID, "error", err)
+		}
+	}
+
+	targets, err := p.router.Load().Targets(f)
+	if err != nil {
+		err = fmt.Errorf("capper %q: %w", f.Capper, err)
+		p.logger.Error("No route for forecast", "id", f.ID, "error", err)
+		p.fail(f, err)
+		return
+	}
+	if len(targets) == 0 {
+		p.logger.Info("Forecast filtered out by routing rules", "id", f.ID, "capper", f.Capper, "sport", f.Sport, "league", f.League, "coef", f.Coef)
+		p.setStatus(f, domain.StatusSkipped, errors.New("no routing rule matched"))
+		return
+	}
+
+	delivered, err := p.delivered(f)
+	if err != nil {
+		p.logger.Error("Load sent messages failed", "id", f.ID, "error", err)
+		p.fail(f, err)
+		return
+	}
+	d := &delivery{sent: len(delivered)}
+	jobs := make([]*job, 0, len(targets))
+	for _, chatID := range targets {
+		if delivered[chatID] {
+			continue
+		}
+		msg, err := p.ps.FormatForecast(f, j.outcome, chatID)
+		if err != nil {
+			p.logger.Error("Format forecast failed", "id", f.ID, "chat_id", chatID, "error", err)
+			p.fail(f, err)
+			return
+		}
+		jobs = append(jobs, &job{forecast: f, outcome: j.outcome, chatID: chatID, message: msg, delivery: d})
+	}
+	if len(jobs) == 0 {
+		p.logger.Info("Forecast already sent to all targets", "id", f.ID, "targets", len(targets))
+		p.setStatus(f, domain.StatusSent, nil)
+		return
+	}
+
+	d.remaining = len(jobs)
+	p.setStatus(f, domain.StatusQueued, nil)
+	for _, sj := range jobs {
+		p.send.schedule(sj)
+	}
+}
+
+// relookup: правка сменила события, а исход найден для прежних. Новые события
+// заново проходят фильтры и дедупликацию, исход для них ищет поллер — задача
+// не возвращается на этап fetch, который при останове закрывается раньше format.
+func (p *Pipeline) relookup(prev, f *domain.Forecast) {
+	p.logger.Info("Forecast edited during outcome lookup, looking up again", "id", f.ID, "teams", f.Teams())
+	p.dedup.Forget(DedupKey(prev))
+	if !p.check(f) {
+		return
+	}
+	p.setStatus(f, domain.StatusPendingOutcome, nil)
+	p.poller.Lookup(f, errors.New("forecast edited during outcome lookup"))
+}
+
+// delivered — каналы, где у анонса уже есть пост
+func (p *Pipeline) delivered(f *domain.Forecast) (map[int64]bool, error) {
+	if f.ID == 0 {
+		return nil, nil
+	}
+	sent, err := p.store.ListSent(f.ID)
+	if err != nil {
+		return nil, err
+	}
+	chats := make(map[int64]bool, len(sent))
+	for _, m := range sent {
+		chats[m.ChatID] = true
+	}
+	return chats, nil
+}
+
+// sendStage вызывается планировщиком после паузы
+func (p *Pipeline) sendStage(j *job) {
+	f, ok := p.current(j.forecast)
+	if !ok {
+		p.logger.Info("Forecast dropped before send, source message deleted", "id", j.forecast.ID, "chat_id", j.chatID)
+		p.finish(j, targetDropped, nil)
+		return
+	}
+	if !p.publishStarted && time.Now().After(f.Kickoff) {
+		err := fmt.Errorf("матч %q начался до отправки", f.Teams())
+		p.logger.Warn("Forecast dropped before send", "id", f.ID, "chat_id", j.chatID, "error", err)
+		p.finish(j, targetSkipped, err)
+		return
+	}
+
+	msg := j.message
+	if edited(j.forecast, f) {
+		var err error
+		if msg, err = p.ps.FormatForecast(f, j.outcome, j.chatID); err != nil {
+			p.logger.Error("Format edited forecast failed", "id", f.ID, "chat_id", j.chatID, "error", err)
+			p.finish(j, targetFailed, err)
+			return
+		}
+	}
+
+	sentID, err := p.sender.SendMessage(p.ctx, j.chatID, msg)
+	if errors.Is(err, context.Canceled) {
+		p.flushStage(j)
+		return
+	}
+	if err != nil {
+		p.metrics.SendFailed(j.chatID)
+		p.logger.Error("SendMessage failed", "id", f.ID, "chat_id", j.chatID, "error", err)
+		p.finish(j, targetFailed, fmt.Errorf("send message to %d: %w", j.chatID, err))
+		return
+	}
+	p.metrics.MessageSent(j.chatID)
+	if f.ID != 0 {
+		if err := p.store.MarkSent(f.ID, j.chatID, sentID); err != nil {
+			p.logger.Error("Mark forecast sent failed", "id", f.ID, "error", err)
+		}
+		p.catchUp(f, j.outcome, j.chatID, sentID)
+	}
+	p.finish(j, targetSent, nil)
+}
+
+// catchUp применяет к только что отправленному посту правку или удаление
+// источника, случившиеся во время отправки: onEdited и onDeleted видят пост
+// лишь после MarkSent
+func (p *Pipeline) catchUp(sent *domain.Forecast, o domain.Outcome, chatID, messageID int64) {
+	f, ok := p.current(sent)
+	sync := p.router.Load().Sync(sent, chatID)
+	switch {
+	case !ok && sync.Deletes:
+		if err := p.sender.DeleteMessages(p.ctx, chatID, []int64{messageID}); err != nil {
+			p.logger.Error("Delete published forecast failed", "id", sent.ID, "chat_id", chatID, "error", err)
+		}
+	case ok && sync.Edits && edited(sent, f):
+		msg, err := p.ps.FormatForecast(f, o, chatID)
+		if err != nil {
+			p.logger.Error("Format edited forecast failed", "id", f.ID, "chat_id", chatID, "error", err)
+			return
+		}
+		if err := p.sender.EditMessage(p.ctx, chatID, messageID, msg); err != nil {
+			p.logger.Error("Edit published forecast failed", "id", f.ID, "chat_id", chatID, "message_id", messageID, "error", err)
+		}
+	}
+}
+
+// flushStage вызывается для неотправленного при останове: прогноз остаётся
+// в хранилище со статусом queued и будет отправлен после перезапуска
+func (p *Pipeline) flushStage(j *job) {
+	p.logger.Info("Unsent forecast flushed to storage", "id", j.forecast.ID, "chat_id", j.chatID)
+	p.finish(j, targetFlushed, nil)
+}
+
+// finish учитывает результат отправки в один канал, а после последнего канала
+// выставляет итоговый статус анонса
+func (p *Pipeline) finish(j *job, res targetResult, err error) {
+	d := j.delivery
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch res {
+	case targetSent:
+		d.sent++
+	case targetFailed:
+		d.failed = err
+	case targetSkipped:
+		d.skipped = err
+	case targetFlushed:
+		d.flushed = true
+	case targetDropped:
+		d.dropped = true
+	}
+	d.remaining--
+	if d.remaining > 0 {
+		return
+	}
+
+	f := j.forecast
+	switch {
+	case d.dropped:
+		// статус deleted уже выставлен
+	case d.flushed:
+		p.setStatus(f, domain.StatusQueued, errors.New("shutdown before send"))
+	case d.sent > 0:
+		// неудача в одном из каналов остаётся в истории как причина
+		p.setStatus(f, domain.StatusSent, d.failed)
+	case d.failed != nil:
+		p.fail(f, d.failed)
+	default:
+		p.setStatus(f, domain.StatusSkipped, d.skipped)
+	}
+}
+
+// fail фиксирует ошибку и даёт повторной доставке того же анонса шанс пройти.
+// Анонс, у которого уже есть пост хотя бы в одном канале, остаётся отправленным:
+// ключ дедупликации не освобождается, чтобы повтор не продублировал пост.
+func (p *Pipeline) fail(f *domain.Forecast, reason error) {
+	delivered, err := p.delivered(f)
+	if err != nil {
+		p.logger.Error("Load sent messages failed", "id", f.ID, "error", err)
+	}
+	if len(delivered) > 0 {
+		p.setStatus(f, domain.StatusSent, reason)
+		return
+	}
+	p.setStatus(f, domain.StatusFailed, reason)
+	p.dedup.Forget(DedupKey(f))
+}
+
+func (p *Pipeline) setStatus(f *domain.Forecast, status domain.ForecastStatus, reason error) {
+	if f.ID == 0 {
+		return
+	}
+	var text string
+	if reason != nil {
+		text = reason.Error()
+	}
+	if err := p.store.SetStatus(f.ID, status, text); err != nil {
+		p.logger.Error("Save forecast status failed", "id", f.ID, "status", status, "error", err)
+	}
+}
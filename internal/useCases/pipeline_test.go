@@ -0,0 +1,230 @@
+package prediction
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/adapters/sqlite"
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/larriantoniy/tg_pipe_bot/internal/dedup"
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/parse"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+)
+
+const announcement = "Каппер - Tester добавил,\nНовый прогноз - -\nФутбол\nАПЛ\nАрсенал - Челси,\nНачало матча завтра 21:00\nКФ 1.85"
+
+// plainText — разметка не разбирается, текст уходит как есть
+type plainText struct{}
+
+func (plainText) ParseText(text, mode string) (domain.FormattedText, error) {
+	return domain.PlainText(text), nil
+}
+
+// recordingSender запоминает отправленное в каналы
+type recordingSender struct {
+	mu     sync.Mutex
+	nextID int64
+	sent   []string
+}
+
+func (s *recordingSender) SendMessage(ctx context.Context, chatID int64, msg domain.OutgoingMessage) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	s.sent = append(s.sent, fmt.Sprintf("%d: %s", chatID, msg.Text.Text))
+	return s.nextID, nil
+}
+
+func (s *recordingSender) EditMessage(ctx context.Context, chatID, messageID int64, msg domain.OutgoingMessage) error {
+	return nil
+}
+
+func (s *recordingSender) DeleteMessages(ctx context.Context, chatID int64, messageIDs []int64) error {
+	return nil
+}
+
+func (s *recordingSender) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.sent...)
+}
+
+// heldFetcher находит исход, только когда тест отпустит запрос
+type heldFetcher struct {
+	started chan *domain.Forecast
+	release chan struct{}
+}
+
+func (h *heldFetcher) FetchOutcome(ctx context.Context, f *domain.Forecast) (domain.Outcome, error) {
+	h.started <- f
+	select {
+	case <-h.release:
+		return domain.Outcome{Text: "П1"}, nil
+	case <-ctx.Done():
+		return domain.Outcome{}, ctx.Err()
+	}
+}
+
+type testPipeline struct {
+	*Pipeline
+	store  *sqlite.Storage
+	dedup  *dedup.Store
+	sender *recordingSender
+}
+
+// newTestPipeline собирает конвейер на SQLite во временном каталоге
+// с поиском исхода через fetcher (nil — сайты по умолчанию); каналы — catch_all 100
+func newTestPipeline(t *testing.T, fetcher ports.OutcomeFetcher) *testPipeline {
+	t.Helper()
+	t.Setenv("ENV", "dev")
+	t.Setenv("TELEGRAM_API_ID", "1")
+	t.Setenv("TELEGRAM_API_HASH", "hash")
+	t.Setenv("BASE_PREDICTION_URL", "http://capper.test")
+	t.Setenv("STORAGE_PATH", t.TempDir()+"/pipebot.db")
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Routing.Discovery.Disabled = true
+	cfg.Routing.CatchAll = []int64{100}
+	cfg.Pipeline.SendDelayMin, cfg.Pipeline.SendDelayMax = 0, 0
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store, err := sqlite.New(logger, cfg.Storage.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	templates, err := NewTemplates(cfg.Templates, cfg.Scraper.BaseURL, plainText{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsers := parse.NewDefaultRegistry(parse.NewKickoffParser(cfg.Time.SourceLocation()))
+	ps := NewPredictionService(logger, cfg, parsers, nopMetrics{}, nil, templates)
+	if fetcher != nil {
+		ps.outcomes = fetcher
+	}
+
+	tp := &testPipeline{store: store, dedup: dedup.New(logger, time.Hour, nil), sender: &recordingSender{}}
+	tp.Pipeline = NewPipeline(logger, cfg, PipelineDeps{
+		Service: ps,
+		Store:   store,
+		Pending: store,
+		Dedup:   tp.dedup,
+		Sender:  tp.sender,
+		Metrics: nopMetrics{},
+	})
+	return tp
+}
+
+// waitFor ждёт, пока cond не станет истинным
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Правка, сменившая команды, пока искался исход, доходит до format уже после
+// закрытия fetch при останове: повторный поиск уходит в поллер и сохраняется
+func TestPipelineEditDuringStop(t *testing.T) {
+	fetcher := &heldFetcher{started: make(chan *domain.Forecast, 1), release: make(chan struct{})}
+	p := newTestPipeline(t, fetcher)
+	p.Start(context.Background())
+
+	p.Process(domain.Message{ID: 1, ChatID: 7, Text: announcement})
+	prev := <-fetcher.started
+
+	edited := strings.Replace(announcement, "Арсенал - Челси", "Ливерпуль - Эвертон", 1)
+	p.Process(domain.Message{Event: domain.MessageEdited, ID: 1, ChatID: 7, Text: edited})
+	waitFor(t, "edit saved", func() bool {
+		rec, err := p.store.FindBySource(7, 1)
+		return err == nil && rec.Forecast.HomeTeam == "Ливерпуль"
+	})
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- p.Stop(context.Background()) }()
+	// fetch закрывается, пока воркер ждёт сайт
+	time.Sleep(50 * time.Millisecond)
+	close(fetcher.release)
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+
+	rec, err := p.store.FindBySource(7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Status != domain.StatusPendingOutcome {
+		t.Errorf("status = %s, want %s", rec.Status, domain.StatusPendingOutcome)
+	}
+	pending, err := p.store.ListPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ForecastID != rec.Forecast.ID {
+		t.Errorf("pending lookups = %+v, want one for forecast %d", pending, rec.Forecast.ID)
+	}
+	if !p.dedup.Remember(DedupKey(prev)) {
+		t.Error("key of the teams before the edit is still taken")
+	}
+	if p.dedup.Remember(DedupKey(&rec.Forecast)) {
+		t.Error("key of the edited teams was not remembered")
+	}
+	if sent := p.sender.messages(); len(sent) != 0 {
+		t.Errorf("sent %v, want nothing before the new lookup", sent)
+	}
+}
+
+// Без селекторов экспресса экспресс пропускается, а не падает ошибкой поиска
+func TestPipelineSkipsExpressWithoutSelectors(t *testing.T) {
+	p := newTestPipeline(t, nil)
+	p.Start(context.Background())
+
+	text := "Каппер - Tester добавил,\nНовый экспресс - -\nФутбол\n" +
+		"АПЛ\nАрсенал - Челси,\nНачало матча завтра 21:00\n" +
+		"Ла Лига\nБарселона - Реал,\nНачало матча завтра 23:00\nКФ 3.5"
+	p.Process(domain.Message{ID: 1, ChatID: 7, Text: text})
+	waitFor(t, "express skipped", func() bool {
+		rec, err := p.store.FindBySource(7, 1)
+		return err == nil && rec.Status != domain.StatusParsed
+	})
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := p.store.FindBySource(7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Status != domain.StatusSkipped || !strings.Contains(rec.Error, "selectors.express.event") {
+		t.Errorf("status = %s (%s), want %s with the missing selector", rec.Status, rec.Error, domain.StatusSkipped)
+	}
+	if pending, _ := p.store.ListPending(); len(pending) != 0 {
+		t.Errorf("pending lookups = %+v, want none", pending)
+	}
+	if !p.dedup.Remember(DedupKey(&rec.Forecast)) {
+		t.Error("key of the skipped express is still taken")
+	}
+	if sent := p.sender.messages(); len(sent) != 0 {
+		t.Errorf("sent %v, want nothing", sent)
+	}
+}
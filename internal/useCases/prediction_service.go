@@ -1,146 +1,135 @@
 package prediction
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
-	"regexp"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
 	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/parse"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
 )
 
-type PredictionService struct {
-	outcomeRe    *regexp.Regexp
-	logger       *slog.Logger
-	coefRe       *regexp.Regexp
-	capperLineRe *regexp.Regexp
-	teamsLineRe  *regexp.Regexp
-	startLineRe  *regexp.Regexp
-}
+var (
+	_ ports.ForecastParser    = (*PredictionService)(nil)
+	_ ports.OutcomeFetcher    = (*PredictionService)(nil)
+	_ ports.ForecastFormatter = (*PredictionService)(nil)
+	_ ports.SiteChecker       = (*PredictionService)(nil)
+)
 
-func NewPredictionService(logger *slog.Logger) *PredictionService {
-	return &PredictionService{
-		logger:       logger,
-		coefRe:       regexp.MustCompile(`~?\s*\d+(?:[.,]\d+)?`),
-		capperLineRe: regexp.MustCompile(`^Каппер\s*-\s*([^\s,]+)(?:\s+добавил)?[,;]?\s*$`),
-		teamsLineRe:  regexp.MustCompile(`^\s*.+\s-\s.+,\s*$`),
-		startLineRe:  regexp.MustCompile(`(?i)^Начало\s+матча\s+(.+)$`),
-	}
-}
-func (p *PredictionService) FormatBetMessage(
-	sport, league, date, teams, outcome, coef string,
-) string {
+// ErrBetNotFound — на странице каппера ещё нет ставки на матч из анонса
+var ErrBetNotFound = ports.ErrBetNotFound
 
-	var b strings.Builder
+// ErrExpressUnsupported — у сайта не заданы селекторы экспресса
+var ErrExpressUnsupported = ports.ErrExpressUnsupported
 
-	// Заголовок
-	if sport != "" {
-		fmt.Fprintln(&b, sport)
-	}
-	if league != "" {
-		fmt.Fprintln(&b, league)
-	}
-	if sport != "" || league != "" {
-		fmt.Fprintln(&b)
-	}
+type PredictionService struct {
+	logger  *slog.Logger
+	baseURL string
+	client  *http.Client
+	timeCfg config.TimeConfig
+	parsers *parse.Registry
+	metrics ports.Metrics
+	// outcomes — сайты капперов в порядке приоритета
+	outcomes ports.OutcomeFetcher
+
+	templates atomic.Pointer[Templates]
+}
 
-	// Основной блок
-	fmt.Fprintf(&b, "🕓 %s\n", strings.TrimSpace(date))
-	fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(teams))
+// NewPredictionService создаёт сервис; transport — путь до сайтов капперов (прокси), nil — напрямую
+func NewPredictionService(logger *slog.Logger, cfg *config.Config, parsers *parse.Registry, metrics ports.Metrics, transport http.RoundTripper, templates *Templates) *PredictionService {
+	client := &http.Client{Timeout: cfg.Scraper.Timeout, Transport: transport}
 
-	// Исход
-	if strings.TrimSpace(outcome) == "" {
-		outcome = "—"
+	providerCfgs := cfg.Scraper.OutcomeProviders()
+	providers := make([]ports.OutcomeProvider, 0, len(providerCfgs))
+	for _, pc := range providerCfgs {
+		providers = append(providers, NewSiteProvider(pc, client, metrics))
 	}
-	fmt.Fprintf(&b, "🎯 %s\n", strings.TrimSpace(outcome))
 
-	// Коэффициент
-	coef = strings.TrimSpace(coef)
-	if coef == "" {
-		coef = "?"
+	p := &PredictionService{
+		logger:   logger,
+		baseURL:  strings.TrimRight(cfg.Scraper.BaseURL, "/") + "/",
+		client:   client,
+		timeCfg:  cfg.Time,
+		parsers:  parsers,
+		metrics:  metrics,
+		outcomes: NewOutcomeChain(logger, providers, cfg.Scraper.Cappers),
 	}
+	p.templates.Store(templates)
+	return p
+}
 
-	fmt.Fprintf(&b, "📈 Кф: %s", coef)
-
-	return b.String()
+// FormatForecast формирует сообщение по шаблону целевого канала, время — в его часовом поясе
+func (p *PredictionService) FormatForecast(f *domain.Forecast, o domain.Outcome, chatID int64) (domain.OutgoingMessage, error) {
+	return p.templates.Load().Execute(f, o, chatID, p.timeCfg.TargetLocation(chatID))
 }
 
-func (p *PredictionService) GetOutcomeOnly(capper, teams, baseURL string) (string, error) {
-	url := fmt.Sprintf("%s%s/bets?_pjax=%%23profile", strings.TrimRight(baseURL, "/")+"/", capper)
+// SetTemplates подменяет шаблоны на ходу
+func (p *PredictionService) SetTemplates(t *Templates) {
+	p.templates.Store(t)
+}
 
-	client := http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("не удалось загрузить страницу: %w", err)
+// formatCoef предпочитает кф с сайта приблизительному «~2» из анонса
+func formatCoef(f *domain.Forecast, o domain.Outcome) string {
+	if !o.Coef.IsZero() {
+		return o.Coef.String()
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("статус %d при загрузке %s", resp.StatusCode, url)
+	if f.Coef.IsZero() {
+		return "?"
+	}
+	if f.CoefApprox {
+		return "~" + f.Coef.String()
 	}
+	return f.Coef.String()
+}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+// FetchOutcome находит исход ставки из анонса на сайтах капперов
+func (p *PredictionService) FetchOutcome(ctx context.Context, f *domain.Forecast) (domain.Outcome, error) {
+	return p.outcomes.FetchOutcome(ctx, f)
+}
+
+// CheckSite проверяет, что сайт капперов отвечает; 4xx считаются ответом
+func (p *PredictionService) CheckSite(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, p.baseURL, http.NoBody)
 	if err != nil {
-		return "", fmt.Errorf("ошибка парсинга HTML: %w", err)
+		return fmt.Errorf("некорректный запрос: %w", err)
 	}
-
-	// Подготовим искомые команды
-	a, b := splitTeams(teams)
-	if a == "" || b == "" {
-		return "", fmt.Errorf("не удалось разделить команды: %q", teams)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("сайт недоступен: %w", err)
 	}
-	na, nb := normalizeName(a), normalizeName(b)
-
-	var (
-		outcome string
-		found   bool
-	)
-
-	doc.Find(".UserBet").EachWithBreak(func(i int, bet *goquery.Selection) bool {
-		// Собираем названия команд из .sides span
-		var left, right []string
-		bet.Find(".sides span").Each(func(i int, s *goquery.Selection) {
-			txt := strings.TrimSpace(s.Text())
-			if txt != "" {
-				if len(left) == 0 {
-					left = append(left, txt)
-				} else {
-					right = append(right, txt)
-				}
-			}
-		})
-		team1 := strings.Join(left, " ")
-		team2 := strings.Join(right, " ")
-
-		// Нормализуем
-		nsides1 := normalizeName(team1)
-		nsides2 := normalizeName(team2)
-
-		// Совпадение: обе искомые команды присутствуют (порядок неважен)
-		match := (teamNamesMatch(na, nsides1) && teamNamesMatch(nb, nsides2)) ||
-			(teamNamesMatch(nb, nsides1) && teamNamesMatch(na, nsides2))
-		if !match {
-			return true // continue
-		}
-
-		// 1) приоритет — mobile-ячейка исхода
-		rawOutcome := bet.Find(".exspres .col-6.d-block.d-md-none.order-1").First().Text()
-		outcome = strings.TrimSpace(strings.Join(strings.Fields(rawOutcome), " "))
-		found = true
-		return false // stop
-	})
-
-	if !found {
-		return "", fmt.Errorf("ставка для матча %q не найдена", teams)
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("статус %d от %s", resp.StatusCode, p.baseURL)
 	}
-	if outcome == "" {
-		return "", fmt.Errorf("исход не найден для матча %q", teams)
+	return nil
+}
+
+// DedupKey строит ключ дедупликации: каппер + нормализованные команды + начало матча.
+// Команды сортируются, чтобы перестановка хозяев и гостей не давала новый ключ.
+func DedupKey(f *domain.Forecast) string {
+	events := []string{dedupEvent(f.HomeTeam, f.AwayTeam, f.Kickoff)}
+	if len(f.Legs) > 0 {
+		events = events[:0]
+		for _, leg := range f.Legs {
+			events = append(events, dedupEvent(leg.HomeTeam, leg.AwayTeam, leg.Kickoff))
+		}
+		sort.Strings(events)
 	}
-	return outcome, nil
+	return strings.ToLower(f.Capper) + "|" + strings.Join(events, "|")
+}
+
+func dedupEvent(home, away string, kickoff time.Time) string {
+	teams := []string{normalizeName(home), normalizeName(away)}
+	sort.Strings(teams)
+	return teams[0] + "/" + teams[1] + "@" + kickoff.UTC().Format(time.RFC3339)
 }
 
 // --- helpers ---
@@ -157,19 +146,6 @@ func normalizeName(s string) string {
 	return strings.ToLower(s)
 }
 
-func splitTeams(teams string) (string, string) {
-	t := strings.ReplaceAll(teams, "—", "-")
-	t = strings.ReplaceAll(t, "–", "-")
-	t = strings.ReplaceAll(t, "−", "-")
-	parts := strings.Split(t, "-")
-	if len(parts) >= 2 {
-		a := strings.TrimSpace(parts[0])
-		b := strings.TrimSpace(strings.Join(parts[1:], "-"))
-		return a, b
-	}
-	return strings.TrimSpace(teams), ""
-}
-
 func teamNamesMatch(expected, actual string) bool {
 	if expected == "" || actual == "" {
 		return false
@@ -241,113 +217,42 @@ func withinCharDiff(a, b string, limit int) bool {
 	return true
 }
 
-// ExtractCapperAndMatch парсит ТОЛЬКО сообщения строго заданного формата.
-// / вида
-//
-// Каппер - NeNaZavode добавил,
-// Новый прогноз - -
-// Футбол
-// Чемпионат Бразилии. Лига Кариока B2
-// Рио-де-Жанейро - Серра Макаенсе,
-// Начало матча 02 ноября 21:00
-// КФ ~2, Ставка 400у.е.
-
-// Возвращает ошибку, если формат не совпадает или найден исход (ставка).
-func (p *PredictionService) ExtractCapperAndMatch(message string) (
-	capper string,
-	sport string,
-	league string,
-	teams string,
-	date string,
-	coef string,
-	err error,
-) {
-	// normalize
-	msg := strings.ReplaceAll(message, "\r\n", "\n")
-	msg = strings.ReplaceAll(msg, "\r", "\n")
-
-	lines := []string{}
-	for _, l := range strings.Split(msg, "\n") {
-		trim := strings.TrimSpace(l)
-		if trim != "" {
-			lines = append(lines, trim)
-		}
-	}
-
-	// We expect at least 7 meaningful lines
-	if len(lines) < 7 {
-		return "", "", "", "", "", "", errors.New("неполное сообщение")
-	}
-
-	// 1) Каппер
-	if m := p.capperLineRe.FindStringSubmatch(lines[0]); len(m) == 2 {
-		capper = m[1]
-	} else {
-		return "", "", "", "", "", "", errors.New("неверная строка каппера")
-	}
-
-	// 2) Проверка маркера
-	if lines[1] != "Новый прогноз - -" {
-		return "", "", "", "", "", "", errors.New("ожидался маркер 'Новый прогноз - -'")
-	}
-
-	// 3) спорт
-	sport = lines[2]
-
-	// 4) лига
-	league = lines[3]
-
-	// 5) команды
-	if !p.teamsLineRe.MatchString(lines[4]) {
-		return "", "", "", "", "", "", fmt.Errorf("некорректная строка команд: %s", lines[4])
+// ParseForecast разбирает входящее сообщение в анонс прогноза
+func (p *PredictionService) ParseForecast(msg domain.Message) (*domain.Forecast, error) {
+	if msg.Text == "" {
+		return nil, errors.New("пустое сообщение или вложение без подписи")
 	}
-	teams = strings.TrimRight(lines[4], ", ")
-
-	// 6) дата
-	if m := p.startLineRe.FindStringSubmatch(lines[5]); len(m) == 2 {
-		date = strings.TrimSpace(m[1]) // "05 ноября 15:15"
-	} else {
-		return "", "", "", "", "", "", errors.New("неверная строка даты")
+	f, err := p.parsers.Parse(msg.Text)
+	if err != nil {
+		return nil, err
 	}
+	f.SourceChatID = msg.ChatID
+	f.SourceMessageID = msg.ID
+	return f, nil
+}
 
-	// 7) коэффициент на последней строке
-	coef = p.coefRe.FindString(lines[6])
-	if coef == "" {
-		return "", "", "", "", "", "", errors.New("не найден коэффициент")
+// checkKind отсекает анонсы, которые пока не публикуются в каналы
+func checkKind(f *domain.Forecast) error {
+	switch f.Kind {
+	case domain.KindNewForecast, domain.KindExpress:
+		return nil
+	case domain.KindEditedForecast:
+		// Исходный анонс уже опубликован; повтор с новым кф или временем дал бы второй пост
+		return fmt.Errorf("изменение ранее опубликованного прогноза %q, пропуск", f.Teams())
+	case domain.KindResult:
+		return fmt.Errorf("уведомление о расчёте прогноза (%s), пропуск", f.Result)
+	default:
+		return fmt.Errorf("неизвестный тип анонса %q", f.Kind)
 	}
-
-	return capper, sport, league, teams, date, coef, nil
 }
 
-func (p *PredictionService) GetFormatedPrediction(msg domain.Message, baseURL string) (string, string, error) {
-	// 1) Достаём capper / teams / sport / league/ date из текста входящего сообщения
-	if msg.Text == "" {
-		return "", "", errors.New("пустое сообщение")
-	}
-	capper, sport, league, teams, date, coef, err := p.ExtractCapperAndMatch(msg.Text)
-	if err != nil {
-		p.logger.Error("extract capper/match failed", "err", err)
-		return "", "", err
+// CheckForecast проверяет, что анонс нужно публиковать: подходящий тип и матч ещё не начался
+func (p *PredictionService) CheckForecast(f *domain.Forecast) error {
+	if err := checkKind(f); err != nil {
+		return err
 	}
-	p.logger.Warn("GetFormatedPrediction AFTER ExtractCapperAndMatch", "capper", capper, " sport", sport, "league", league, "teams", teams, "date", date, "coef", coef)
-
-	// 2) Парсим сайт каппера и находим исход и кф
-	outcome, err := p.GetOutcomeOnly(capper, teams, strings.TrimRight(baseURL, "/")+"/")
-	if err != nil {
-		p.logger.Error("fetch forecast failed", "capper", capper, "teams", teams, "date", date, "err", err)
-		return "", "", err
+	if !p.timeCfg.PublishStarted && time.Now().After(f.Kickoff) {
+		return fmt.Errorf("матч %q уже начался (%s)", f.Teams(), f.Kickoff.Format(time.RFC3339))
 	}
-	p.logger.Warn("GetFormatedPrediction AFTER GETOUTCOME ONLY", "outcome", outcome)
-
-	// 4) Формируем финальный текст сообщения
-	formatted := p.FormatBetMessage(
-		sport,
-		league,
-		date,
-		teams,
-		outcome,
-		coef,
-	)
-
-	return capper, formatted, nil
+	return nil
 }
@@ -0,0 +1,54 @@
+package prediction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+)
+
+func TestDedupKey(t *testing.T) {
+	kickoff := time.Date(2025, 11, 2, 21, 0, 0, 0, time.UTC)
+	msk := time.FixedZone("MSK", 3*60*60)
+	base := domain.Forecast{Capper: "NeNaZavode", HomeTeam: "Рио-де-Жанейро", AwayTeam: "Серра Макаенсе", Kickoff: kickoff}
+	legs := []domain.Leg{
+		{HomeTeam: "Арсенал", AwayTeam: "Челси", Kickoff: kickoff},
+		{HomeTeam: "Барселона", AwayTeam: "Реал Мадрид", Kickoff: kickoff.Add(2 * time.Hour)},
+	}
+	express := domain.Forecast{Capper: "NeNaZavode", HomeTeam: "Арсенал", AwayTeam: "Челси", Kickoff: kickoff, Legs: legs}
+
+	tests := []struct {
+		name string
+		a, b domain.Forecast
+		same bool
+	}{
+		{name: "capper case", a: base, b: with(base, func(f *domain.Forecast) { f.Capper = "nenazavode" }), same: true},
+		{name: "teams swapped", a: base, b: with(base, func(f *domain.Forecast) { f.HomeTeam, f.AwayTeam = f.AwayTeam, f.HomeTeam }), same: true},
+		{name: "spacing and trailing comma", a: base, b: with(base, func(f *domain.Forecast) { f.AwayTeam = " Серра  Макаенсе," }), same: true},
+		{name: "same instant in another zone", a: base, b: with(base, func(f *domain.Forecast) { f.Kickoff = kickoff.In(msk) }), same: true},
+		{name: "coef and league ignored", a: base, b: with(base, func(f *domain.Forecast) { f.League, f.Result = "Лига", "x" }), same: true},
+		{name: "other kickoff", a: base, b: with(base, func(f *domain.Forecast) { f.Kickoff = kickoff.Add(time.Hour) }), same: false},
+		{name: "other capper", a: base, b: with(base, func(f *domain.Forecast) { f.Capper = "Other" }), same: false},
+		{name: "other team", a: base, b: with(base, func(f *domain.Forecast) { f.AwayTeam = "Флуминенсе" }), same: false},
+		{
+			name: "express legs order",
+			a:    express,
+			b:    with(express, func(f *domain.Forecast) { f.Legs = []domain.Leg{legs[1], legs[0]} }),
+			same: true,
+		},
+		{name: "express differs from its first event", a: express, b: with(express, func(f *domain.Forecast) { f.Legs = nil }), same: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ka, kb := DedupKey(&tt.a), DedupKey(&tt.b)
+			if (ka == kb) != tt.same {
+				t.Errorf("keys %q and %q: same = %v, want %v", ka, kb, ka == kb, tt.same)
+			}
+		})
+	}
+}
+
+func with(f domain.Forecast, change func(*domain.Forecast)) domain.Forecast {
+	change(&f)
+	return f
+}
@@ -0,0 +1,175 @@
+package prediction
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+	"github.com/shopspring/decimal"
+)
+
+// ErrNoRoute — каппер не упомянут ни в правилах, ни среди найденных каналов, а catch_all не задан
+var ErrNoRoute = errors.New("no target channel")
+
+// routeRule — правило из конфига с нормализованными фильтрами
+type routeRule struct {
+	name    string
+	cappers map[string]struct{}
+	sports  map[string]struct{}
+	leagues []string
+	minCoef decimal.Decimal
+	maxCoef decimal.Decimal
+	targets []int64
+	// sync — nil, если правило не переопределяет routing.sync
+	sync *config.SyncConfig
+}
+
+// Router выбирает целевые каналы анонса по правилам из конфига
+// и по каналам, найденным по названию (discovery)
+type Router struct {
+	rules    []routeRule
+	catchAll []int64
+	sync     config.SyncConfig
+	// channels — nil, если discovery выключен
+	channels ports.ChannelDirectory
+}
+
+func NewRouter(cfg config.RoutingConfig, channels ports.ChannelDirectory) *Router {
+	r := &Router{catchAll: cfg.CatchAll, sync: cfg.Sync}
+	if !cfg.Discovery.Disabled {
+		r.channels = channels
+	}
+	for _, rc := range cfg.Rules {
+		r.rules = append(r.rules, routeRule{
+			name:    rc.Name,
+			cappers: lowerSet(rc.Cappers),
+			sports:  lowerSet(rc.Sports),
+			leagues: lowerAll(rc.Leagues),
+			minCoef: decimal.NewFromFloat(rc.MinCoef),
+			maxCoef: decimal.NewFromFloat(rc.MaxCoef),
+			targets: rc.Targets,
+			sync:    rc.Sync,
+		})
+	}
+	return r
+}
+
+// Targets возвращает каналы без повторов. Пустой список без ошибки — каппер известен,
+// но фильтры правил анонс не пропустили.
+func (r *Router) Targets(f *domain.Forecast) ([]int64, error) {
+	var (
+		targets []int64
+		known   bool
+	)
+	seen := make(map[int64]struct{})
+	add := func(ids ...int64) {
+		for _, id := range ids {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				targets = append(targets, id)
+			}
+		}
+	}
+
+	if r.channels != nil {
+		if chatID, ok := r.channels.TargetChannel(f.Capper); ok {
+			known = true
+			add(chatID)
+		}
+	}
+	capper := strings.ToLower(f.Capper)
+	for _, rule := range r.rules {
+		if _, ok := rule.cappers[capper]; ok {
+			known = true
+		}
+		if rule.match(capper, f) {
+			add(rule.targets...)
+		}
+	}
+
+	if !known && len(targets) == 0 {
+		if len(r.catchAll) == 0 {
+			return nil, ErrNoRoute
+		}
+		add(r.catchAll...)
+	}
+	return targets, nil
+}
+
+// Sync — политика переноса правок и удалений для поста анонса в канале chatID.
+// Если канал — цель подходящих правил со своим sync, действие разрешено,
+// когда его разрешает хотя бы одно из них; иначе действует routing.sync.
+func (r *Router) Sync(f *domain.Forecast, chatID int64) config.SyncConfig {
+	var (
+		res        config.SyncConfig
+		overridden bool
+	)
+	capper := strings.ToLower(f.Capper)
+	for _, rule := range r.rules {
+		if rule.sync == nil || !slices.Contains(rule.targets, chatID) || !rule.match(capper, f) {
+			continue
+		}
+		overridden = true
+		res.Edits = res.Edits || rule.sync.Edits
+		res.Deletes = res.Deletes || rule.sync.Deletes
+	}
+	if !overridden {
+		return r.sync
+	}
+	return res
+}
+
+func (rule *routeRule) match(capper string, f *domain.Forecast) bool {
+	if len(rule.cappers) > 0 {
+		if _, ok := rule.cappers[capper]; !ok {
+			return false
+		}
+	}
+	if len(rule.sports) > 0 {
+		if _, ok := rule.sports[strings.ToLower(strings.TrimSpace(f.Sport))]; !ok {
+			return false
+		}
+	}
+	if len(rule.leagues) > 0 {
+		league := strings.ToLower(f.League)
+		found := false
+		for _, l := range rule.leagues {
+			if strings.Contains(league, l) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	// Кф неизвестен — правило с границами его не пропускает
+	if rule.minCoef.IsPositive() && (f.Coef.IsZero() || f.Coef.LessThan(rule.minCoef)) {
+		return false
+	}
+	if rule.maxCoef.IsPositive() && (f.Coef.IsZero() || f.Coef.GreaterThan(rule.maxCoef)) {
+		return false
+	}
+	return true
+}
+
+func lowerSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range lowerAll(values) {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+func lowerAll(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
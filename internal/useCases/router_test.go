@@ -0,0 +1,103 @@
+package prediction
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// channelMap — каналы капперов, найденные по названию
+type channelMap map[string]int64
+
+func (m channelMap) TargetChannel(capper string) (int64, bool) {
+	id, ok := m[capper]
+	return id, ok
+}
+
+func TestRouterTargets(t *testing.T) {
+	cfg := config.RoutingConfig{
+		Rules: []config.RouteRule{
+			{Name: "football", Cappers: []string{" NeNaZavode "}, Sports: []string{"Футбол"}, Targets: []int64{10, 11}},
+			{Name: "tennis value", Sports: []string{"теннис"}, MinCoef: 1.8, MaxCoef: 3, Targets: []int64{20}},
+			{Name: "top leagues", Leagues: []string{"премьер-лига"}, Targets: []int64{11, 30}},
+		},
+		CatchAll: []int64{99},
+	}
+	channels := channelMap{"Discovered": 40, "NeNaZavode": 10}
+
+	tests := []struct {
+		name      string
+		discovery bool
+		f         domain.Forecast
+		want      []int64
+		err       error
+	}{
+		{name: "capper and sport rule", f: domain.Forecast{Capper: "nenazavode", Sport: "Футбол", League: "Бразилия"}, want: []int64{10, 11}},
+		{name: "targets deduplicated across rules", f: domain.Forecast{Capper: "NeNaZavode", Sport: "футбол", League: "Англия. Премьер-лига"}, want: []int64{10, 11, 30}},
+		{name: "known capper filtered out", f: domain.Forecast{Capper: "NeNaZavode", Sport: "Хоккей"}, want: nil},
+		{name: "coef within bounds", f: domain.Forecast{Capper: "Any", Sport: "Теннис", Coef: decimal.RequireFromString("2.1")}, want: []int64{20}},
+		{name: "coef below min falls to catch-all", f: domain.Forecast{Capper: "Any", Sport: "Теннис", Coef: decimal.RequireFromString("1.5")}, want: []int64{99}},
+		{name: "unknown coef does not pass bounds", f: domain.Forecast{Capper: "Any", Sport: "Теннис"}, want: []int64{99}},
+		{name: "discovered channel first", discovery: true, f: domain.Forecast{Capper: "NeNaZavode", Sport: "Футбол"}, want: []int64{10, 11}},
+		{name: "discovered channel only", discovery: true, f: domain.Forecast{Capper: "Discovered", Sport: "Баскетбол"}, want: []int64{40}},
+		{name: "discovery disabled", f: domain.Forecast{Capper: "Discovered", Sport: "Баскетбол"}, want: []int64{99}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := cfg
+			rc.Discovery.Disabled = !tt.discovery
+			got, err := NewRouter(rc, channels).Targets(&tt.f)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("targets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("no route without catch-all", func(t *testing.T) {
+		rc := cfg
+		rc.CatchAll = nil
+		rc.Discovery.Disabled = true
+		if _, err := NewRouter(rc, channels).Targets(&domain.Forecast{Capper: "Stranger", Sport: "Футбол"}); !errors.Is(err, ErrNoRoute) {
+			t.Errorf("err = %v, want ErrNoRoute", err)
+		}
+	})
+}
+
+func TestRouterSync(t *testing.T) {
+	cfg := config.RoutingConfig{
+		Rules: []config.RouteRule{
+			{Cappers: []string{"A"}, Targets: []int64{10}, Sync: &config.SyncConfig{Edits: true}},
+			{Sports: []string{"Футбол"}, Targets: []int64{10}, Sync: &config.SyncConfig{Deletes: true}},
+			{Cappers: []string{"A"}, Targets: []int64{20}},
+		},
+		Sync:      config.SyncConfig{Edits: true, Deletes: true},
+		Discovery: config.DiscoveryConfig{Disabled: true},
+	}
+	r := NewRouter(cfg, nil)
+
+	tests := []struct {
+		name   string
+		f      domain.Forecast
+		chatID int64
+		want   config.SyncConfig
+	}{
+		{name: "matching overrides are merged", f: domain.Forecast{Capper: "A", Sport: "Футбол"}, chatID: 10, want: config.SyncConfig{Edits: true, Deletes: true}},
+		{name: "single override", f: domain.Forecast{Capper: "A", Sport: "Теннис"}, chatID: 10, want: config.SyncConfig{Edits: true}},
+		{name: "rule without sync uses default", f: domain.Forecast{Capper: "A"}, chatID: 20, want: cfg.Sync},
+		{name: "other chat uses default", f: domain.Forecast{Capper: "B"}, chatID: 99, want: cfg.Sync},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Sync(&tt.f, tt.chatID); got != tt.want {
+				t.Errorf("Sync = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
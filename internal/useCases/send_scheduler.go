@@ -0,0 +1,94 @@
+package prediction
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sendScheduler держит по очереди на каждый целевой чат. Перед каждой отправкой
+// выдерживается случайная пауза, чтобы публикации выглядели «по-человечески»;
+// сообщения в один чат уходят строго по очереди.
+// При останове паузы прерываются, а неотправленное передаётся в flush.
+type sendScheduler struct {
+	queueSize int
+	delays    atomic.Pointer[delayRange]
+	send      func(*job)
+	flush     func(*job)
+
+	mu       sync.Mutex
+	queues   map[int64]chan *job
+	wg       sync.WaitGroup
+	stopping chan struct{}
+}
+
+// delayRange — границы паузы перед отправкой
+type delayRange struct {
+	min, max time.Duration
+}
+
+func newSendScheduler(queueSize int, minDelay, maxDelay time.Duration, send, flush func(*job)) *sendScheduler {
+	s := &sendScheduler{
+		queueSize: queueSize,
+		send:      send,
+		flush:     flush,
+		queues:    make(map[int64]chan *job),
+		stopping:  make(chan struct{}),
+	}
+	s.setDelays(minDelay, maxDelay)
+	return s
+}
+
+// setDelays меняет границы паузы; действует со следующего сообщения
+func (s *sendScheduler) setDelays(minDelay, maxDelay time.Duration) {
+	s.delays.Store(&delayRange{min: minDelay, max: maxDelay})
+}
+
+func (s *sendScheduler) schedule(j *job) {
+	s.mu.Lock()
+	q, ok := s.queues[j.chatID]
+	if !ok {
+		q = make(chan *job, s.queueSize)
+		s.queues[j.chatID] = q
+		s.wg.Add(1)
+		go s.loop(q)
+	}
+	s.mu.Unlock()
+
+	q <- j
+}
+
+func (s *sendScheduler) loop(q chan *job) {
+	defer s.wg.Done()
+	for j := range q {
+		d := s.delays.Load()
+		timer := time.NewTimer(randDuration(d.min, d.max))
+		select {
+		case <-timer.C:
+			s.send(j)
+		case <-s.stopping:
+			timer.Stop()
+			s.flush(j)
+		}
+	}
+}
+
+// stop прерывает паузы: всё, что ещё ждёт отправки, уходит в flush
+func (s *sendScheduler) stop() {
+	close(s.stopping)
+	s.mu.Lock()
+	for _, q := range s.queues {
+		close(q)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func randDuration(minDelay, maxDelay time.Duration) time.Duration {
+	if maxDelay <= minDelay {
+		return minDelay
+	}
+	// равномерно с точностью до миллисекунды, чтобы интервалы выглядели менее ровными
+	return minDelay + time.Duration(rand.Int63n(int64(maxDelay-minDelay)/int64(time.Millisecond)+1))*time.Millisecond
+}
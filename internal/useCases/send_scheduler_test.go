@@ -0,0 +1,148 @@
+package prediction
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+)
+
+// sentJobs запоминает, какие задачи ушли в send и какие в flush
+type sentJobs struct {
+	mu      sync.Mutex
+	sent    map[int64][]int64
+	flushed map[int64][]int64
+}
+
+func newSentJobs() *sentJobs {
+	return &sentJobs{sent: make(map[int64][]int64), flushed: make(map[int64][]int64)}
+}
+
+func (s *sentJobs) send(j *job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent[j.chatID] = append(s.sent[j.chatID], j.forecast.ID)
+}
+
+func (s *sentJobs) flush(j *job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushed[j.chatID] = append(s.flushed[j.chatID], j.forecast.ID)
+}
+
+func (s *sentJobs) count() (sent, flushed int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ids := range s.sent {
+		sent += len(ids)
+	}
+	for _, ids := range s.flushed {
+		flushed += len(ids)
+	}
+	return sent, flushed
+}
+
+func chatJob(chatID, forecastID int64) *job {
+	return &job{chatID: chatID, forecast: &domain.Forecast{ID: forecastID}}
+}
+
+func assertOrder(t *testing.T, what string, got map[int64][]int64, want map[int64][]int64) {
+	t.Helper()
+	for chatID, ids := range want {
+		if len(got[chatID]) != len(ids) {
+			t.Errorf("%s to chat %d = %v, want %v", what, chatID, got[chatID], ids)
+			continue
+		}
+		for i := range ids {
+			if got[chatID][i] != ids[i] {
+				t.Errorf("%s to chat %d = %v, want %v", what, chatID, got[chatID], ids)
+				break
+			}
+		}
+	}
+}
+
+// Сообщения в один чат уходят в порядке постановки
+func TestSendSchedulerOrderPerChat(t *testing.T) {
+	jobs := newSentJobs()
+	s := newSendScheduler(16, 0, time.Millisecond, jobs.send, jobs.flush)
+
+	want := map[int64][]int64{100: {1, 3, 5, 7}, 200: {2, 4, 6}}
+	for id := int64(1); id <= 7; id++ {
+		chatID := int64(100)
+		if id%2 == 0 {
+			chatID = 200
+		}
+		s.schedule(chatJob(chatID, id))
+	}
+	waitFor(t, "all jobs sent", func() bool {
+		sent, _ := jobs.count()
+		return sent == 7
+	})
+	s.stop()
+
+	jobs.mu.Lock()
+	defer jobs.mu.Unlock()
+	assertOrder(t, "sent", jobs.sent, want)
+	if len(jobs.flushed) != 0 {
+		t.Errorf("flushed %v, want nothing", jobs.flushed)
+	}
+}
+
+// Останов прерывает паузу: ждущие сообщения уходят в flush по порядку, а не отправляются
+func TestSendSchedulerStopInterruptsDelay(t *testing.T) {
+	jobs := newSentJobs()
+	s := newSendScheduler(16, time.Hour, time.Hour, jobs.send, jobs.flush)
+
+	s.schedule(chatJob(100, 1))
+	s.schedule(chatJob(200, 2))
+	s.schedule(chatJob(100, 3))
+	s.schedule(chatJob(100, 4))
+
+	stopped := make(chan struct{})
+	go func() {
+		s.stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop waited for the send delay")
+	}
+
+	sent, flushed := jobs.count()
+	if sent != 0 || flushed != 4 {
+		t.Errorf("sent %d, flushed %d, want 0 and 4", sent, flushed)
+	}
+	assertOrder(t, "flushed", jobs.flushed, map[int64][]int64{100: {1, 3, 4}, 200: {2}})
+}
+
+// Новые границы паузы действуют со следующего сообщения
+func TestSendSchedulerSetDelays(t *testing.T) {
+	jobs := newSentJobs()
+	s := newSendScheduler(16, time.Hour, time.Hour, jobs.send, jobs.flush)
+	s.setDelays(0, 0)
+
+	s.schedule(chatJob(100, 1))
+	waitFor(t, "job sent", func() bool {
+		sent, _ := jobs.count()
+		return sent == 1
+	})
+	s.stop()
+}
+
+func TestRandDuration(t *testing.T) {
+	if d := randDuration(time.Second, time.Second); d != time.Second {
+		t.Errorf("randDuration(1s, 1s) = %s, want 1s", d)
+	}
+	if d := randDuration(2*time.Second, time.Second); d != 2*time.Second {
+		t.Errorf("randDuration with max below min = %s, want min", d)
+	}
+	for i := 0; i < 100; i++ {
+		d := randDuration(10*time.Millisecond, 20*time.Millisecond)
+		if d < 10*time.Millisecond || d > 20*time.Millisecond || d%time.Millisecond != 0 {
+			t.Fatalf("randDuration(10ms, 20ms) = %s, want whole milliseconds within range", d)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package prediction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+	"github.com/shopspring/decimal"
+)
+
+var _ ports.OutcomeProvider = (*SiteProvider)(nil)
+
+// SiteProvider ищет исход на HTML-странице ставок каппера.
+// Адрес страницы и селекторы задаются конфигом, так что редизайн сайта
+// или переезд каппера на другую площадку не требует правки кода.
+type SiteProvider struct {
+	name      string
+	baseURL   string
+	betsPath  string
+	selectors config.OutcomeSelectors
+	client    *http.Client
+	metrics   ports.Metrics
+}
+
+// NewSiteProvider создаёт провайдера; cfg должен быть с заполненными умолчаниями
+func NewSiteProvider(cfg config.OutcomeProviderConfig, client *http.Client, metrics ports.Metrics) *SiteProvider {
+	return &SiteProvider{
+		name:      cfg.Name,
+		baseURL:   strings.TrimRight(cfg.BaseURL, "/") + "/",
+		betsPath:  strings.TrimLeft(cfg.BetsPath, "/"),
+		selectors: cfg.Selectors,
+		client:    client,
+		metrics:   metrics,
+	}
+}
+
+func (s *SiteProvider) Name() string {
+	return s.name
+}
+
+// BetsURL — адрес страницы ставок каппера
+func (s *SiteProvider) BetsURL(capper string) string {
+	return s.baseURL + strings.ReplaceAll(s.betsPath, "{capper}", url.PathEscape(capper))
+}
+
+// FindOutcome загружает страницу ставок и ищет карточку с обеими командами анонса,
+// а для экспресса — карточку, где нашлись все его события
+func (s *SiteProvider) FindOutcome(ctx context.Context, f *domain.Forecast) (domain.Outcome, error) {
+	if len(f.Legs) > 1 {
+		return s.findExpress(ctx, f)
+	}
+
+	teams := f.HomeTeam + " - " + f.AwayTeam
+	if f.HomeTeam == "" || f.AwayTeam == "" {
+		return domain.Outcome{}, fmt.Errorf("не удалось разделить команды: %q", teams)
+	}
+
+	doc, err := s.load(ctx, s.BetsURL(f.Capper))
+	if err != nil {
+		return domain.Outcome{}, err
+	}
+
+	na, nb := normalizeName(f.HomeTeam), normalizeName(f.AwayTeam)
+	var (
+		outcome domain.Outcome
+		found   bool
+	)
+	doc.Find(s.selectors.Bet).EachWithBreak(func(i int, bet *goquery.Selection) bool {
+		// Экспресс с этим матчем — другая ставка, у ординара событие одно
+		if s.selectors.Express.Event != "" && bet.Find(s.selectors.Express.Event).Length() > 1 {
+			return true // continue
+		}
+		if !sidesMatch(na, nb, betTeams(bet, s.selectors.Teams)) {
+			return true
+		}
+		outcome = s.parseBet(bet)
+		found = true
+		return false // stop
+	})
+
+	if !found {
+		return domain.Outcome{}, fmt.Errorf("матч %q: %w", teams, ErrBetNotFound)
+	}
+	if outcome.Text == "" {
+		return domain.Outcome{}, fmt.Errorf("исход не найден для матча %q", teams)
+	}
+	return outcome, nil
+}
+
+// findExpress ищет карточку экспресса с тем же числом событий, где каждому
+// событию анонса соответствует своё событие на сайте
+func (s *SiteProvider) findExpress(ctx context.Context, f *domain.Forecast) (domain.Outcome, error) {
+	if s.selectors.Express.Event == "" {
+		return domain.Outcome{}, fmt.Errorf("селекторы экспресса не заданы (selectors.express.event): %w", ErrExpressUnsupported)
+	}
+	doc, err := s.load(ctx, s.BetsURL(f.Capper))
+	if err != nil {
+		return domain.Outcome{}, err
+	}
+
+	var (
+		outcome domain.Outcome
+		found   bool
+	)
+	doc.Find(s.selectors.Bet).EachWithBreak(func(i int, bet *goquery.Selection) bool {
+		events := bet.Find(s.selectors.Express.Event)
+		if events.Length() != len(f.Legs) {
+			return true
+		}
+		matched, ok := s.matchLegs(f.Legs, events)
+		if !ok {
+			return true
+		}
+		outcome = s.parseExpress(bet, matched)
+		found = true
+		return false
+	})
+
+	if !found {
+		teams := make([]string, len(f.Legs))
+		for i := range f.Legs {
+			teams[i] = f.Legs[i].Teams()
+		}
+		return domain.Outcome{}, fmt.Errorf("экспресс %q: %w", strings.Join(teams, "; "), ErrBetNotFound)
+	}
+	for i, leg := range outcome.Legs {
+		if leg.Text == "" {
+			return domain.Outcome{}, fmt.Errorf("исход не найден для события %q экспресса", f.Legs[i].Teams())
+		}
+	}
+	return outcome, nil
+}
+
+// matchLegs сопоставляет события анонса событиям карточки, каждое — не более одного раза
+func (s *SiteProvider) matchLegs(legs []domain.Leg, events *goquery.Selection) ([]*goquery.Selection, bool) {
+	sides := make([][2]string, events.Length())
+	events.Each(func(i int, ev *goquery.Selection) {
+		sides[i] = betTeams(ev, s.selectors.Express.Teams)
+	})
+
+	used := make([]bool, len(sides))
+	matched := make([]*goquery.Selection, len(legs))
+	for i, leg := range legs {
+		na, nb := normalizeName(leg.HomeTeam), normalizeName(leg.AwayTeam)
+		for j := range sides {
+			if !used[j] && sidesMatch(na, nb, sides[j]) {
+				used[j] = true
+				matched[i] = events.Eq(j)
+				break
+			}
+		}
+		if matched[i] == nil {
+			return nil, false
+		}
+	}
+	return matched, true
+}
+
+// parseExpress собирает ставку экспресса; если общий кф на сайте не указан,
+// он считается произведением кф событий
+func (s *SiteProvider) parseExpress(bet *goquery.Selection, events []*goquery.Selection) domain.Outcome {
+	sel := s.selectors.Express
+	o := domain.Outcome{
+		Coef:    parseCoef(firstText(bet, sel.TotalCoef)),
+		Stake:   parseNumber(firstText(bet, s.selectors.Stake)),
+		BetType: domain.BetExpress,
+		League:  firstText(bet, s.selectors.League),
+		Legs:    make([]domain.LegOutcome, len(events)),
+	}
+
+	texts := make([]string, len(events))
+	product := decimal.NewFromInt(1)
+	for i, ev := range events {
+		leg := domain.LegOutcome{
+			Text: firstText(ev, sel.Outcome),
+			Coef: parseCoef(firstText(ev, sel.Coef)),
+		}
+		o.Legs[i] = leg
+		texts[i] = leg.Text
+		if product.IsPositive() && !leg.Coef.IsZero() {
+			product = product.Mul(leg.Coef)
+		} else {
+			product = decimal.Zero
+		}
+	}
+	o.Text = strings.Join(texts, "; ")
+	if o.Coef.IsZero() && product.IsPositive() {
+		o.Coef = product.Round(2)
+	}
+	return o
+}
+
+func (s *SiteProvider) load(ctx context.Context, pageURL string) (*goquery.Document, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный запрос: %w", err)
+	}
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.metrics.ScrapeObserved("error", time.Since(start))
+		return nil, fmt.Errorf("не удалось загрузить страницу: %w", err)
+	}
+	defer resp.Body.Close()
+	s.metrics.ScrapeObserved(strconv.Itoa(resp.StatusCode), time.Since(start))
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{code: resp.StatusCode, url: pageURL}
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга HTML: %w", err)
+	}
+	return doc, nil
+}
+
+// statusError — сайт ответил не 200
+type statusError struct {
+	code int
+	url  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("статус %d при загрузке %s", e.code, e.url)
+}
+
+// retryable — после такой ошибки поиск исхода стоит повторить: ставки на странице
+// ещё нет или сайт временно недоступен. Остальное (нет исхода в карточке,
+// не разделились команды, 404) повтор не исправит.
+func retryable(err error) bool {
+	if errors.Is(err, ErrBetNotFound) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= http.StatusInternalServerError || se.code == http.StatusTooManyRequests
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// betTeams: первый непустой элемент — хозяева, остальные вместе — гости; имена нормализованы
+func betTeams(bet *goquery.Selection, selector string) [2]string {
+	var left, right []string
+	bet.Find(selector).Each(func(i int, sel *goquery.Selection) {
+		txt := strings.TrimSpace(sel.Text())
+		if txt == "" {
+			return
+		}
+		if len(left) == 0 {
+			left = append(left, txt)
+		} else {
+			right = append(right, txt)
+		}
+	})
+	return [2]string{normalizeName(strings.Join(left, " ")), normalizeName(strings.Join(right, " "))}
+}
+
+// sidesMatch: обе искомые команды присутствуют (порядок неважен)
+func sidesMatch(na, nb string, sides [2]string) bool {
+	return (teamNamesMatch(na, sides[0]) && teamNamesMatch(nb, sides[1])) ||
+		(teamNamesMatch(nb, sides[0]) && teamNamesMatch(na, sides[1]))
+}
+
+// parseBet собирает ставку из карточки
+func (s *SiteProvider) parseBet(bet *goquery.Selection) domain.Outcome {
+	return domain.Outcome{
+		Text:    firstText(bet, s.selectors.Outcome),
+		Coef:    parseCoef(firstText(bet, s.selectors.Coef)),
+		Stake:   parseNumber(firstText(bet, s.selectors.Stake)),
+		BetType: parseBetType(firstText(bet, s.selectors.BetType)),
+		League:  firstText(bet, s.selectors.League),
+	}
+}
+
+// firstText — текст первой непустой ячейки по порядку селекторов, пробелы схлопнуты
+func firstText(bet *goquery.Selection, selectors []string) string {
+	for _, sel := range selectors {
+		raw := bet.Find(sel).First().Text()
+		if text := strings.Join(strings.Fields(raw), " "); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+var numberRe = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+
+// parseNumber достаёт первое число из ячейки вида «1,85», «10 000 ₽» или «5%»
+func parseNumber(text string) decimal.Decimal {
+	m := numberRe.FindString(strings.Join(strings.Fields(text), ""))
+	if m == "" {
+		return decimal.Zero
+	}
+	d, err := decimal.NewFromString(strings.Replace(m, ",", ".", 1))
+	if err != nil {
+		return decimal.Zero
+	}
+	return d
+}
+
+// parseCoef — кф из ячейки. Десятичный кф всегда больше единицы;
+// иное — не кф, а соседняя ячейка, и тогда возвращается ноль.
+func parseCoef(text string) decimal.Decimal {
+	if coef := parseNumber(text); coef.GreaterThan(decimal.NewFromInt(1)) {
+		return coef
+	}
+	return decimal.Zero
+}
+
+func parseBetType(text string) domain.BetType {
+	text = strings.ToLower(text)
+	switch {
+	case strings.Contains(text, "экспресс"), strings.Contains(text, "express"):
+		return domain.BetExpress
+	case strings.Contains(text, "ординар"), strings.Contains(text, "single"):
+		return domain.BetSingle
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,170 @@
+package prediction
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+type nopMetrics struct{}
+
+func (nopMetrics) MessageReceived(int64)                {}
+func (nopMetrics) ParseFailed(string, string)           {}
+func (nopMetrics) ScrapeObserved(string, time.Duration) {}
+func (nopMetrics) CacheLookup(string)                   {}
+func (nopMetrics) MessageSent(int64)                    {}
+func (nopMetrics) SendFailed(int64)                     {}
+
+// serveBets отдаёт page как страницу ставок каппера Tester
+func serveBets(t *testing.T, page []byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Tester/bets" || r.URL.Query().Get("_pjax") != "#profile" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(page)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSiteProviderDefaultSelectors(t *testing.T) {
+	page, err := os.ReadFile("testdata/capper_bets.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := serveBets(t, page)
+	cfg := config.ScraperConfig{BaseURL: srv.URL}
+	p := NewSiteProvider(cfg.OutcomeProviders()[0], srv.Client(), nopMetrics{})
+
+	tests := []struct {
+		name       string
+		home, away string
+		outcome    string
+		coef       string
+		// notFound — ставки нет, поиск повторится; failed — ошибка без повтора
+		notFound bool
+		failed   bool
+	}{
+		{name: "mobile cell", home: "Рио-де-Жанейро", away: "Серра Макаенсе", outcome: "П1", coef: "1.85"},
+		{name: "teams swapped", home: "Серра Макаенсе", away: "Рио-де-Жанейро", outcome: "П1", coef: "1.85"},
+		{name: "desktop fallback", home: "Арсенал", away: "Челси", outcome: "ТБ 2.5", coef: "2.1"},
+		{name: "not on page yet", home: "Бавария", away: "Боруссия", notFound: true},
+		{name: "card without outcome", home: "Зенит", away: "Спартак", failed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &domain.Forecast{Capper: "Tester", HomeTeam: tt.home, AwayTeam: tt.away}
+			o, err := p.FindOutcome(context.Background(), f)
+			if tt.notFound || tt.failed {
+				if err == nil || errors.Is(err, ErrBetNotFound) != tt.notFound || retryable(err) != tt.notFound {
+					t.Fatalf("err = %v, want not found %v", err, tt.notFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.Text != tt.outcome {
+				t.Errorf("outcome = %q, want %q", o.Text, tt.outcome)
+			}
+			if !o.Coef.Equal(decimal.RequireFromString(tt.coef)) {
+				t.Errorf("coef = %s, want %s", o.Coef, tt.coef)
+			}
+		})
+	}
+}
+
+const configuredPage = `
+<div class="UserBet">
+  <div class="bet-kind">Экспресс</div>
+  <div class="bet-stake">500 ₽</div>
+  <div class="express-event">
+    <div class="sides"><span>Арсенал</span><span>Челси</span></div>
+    <div class="exspres"><div class="col-6 d-block d-md-none order-1">П1</div><div class="col-6 d-block d-md-none order-2">1.90</div></div>
+  </div>
+  <div class="express-event">
+    <div class="sides"><span>Барселона</span><span>Реал Мадрид</span></div>
+    <div class="exspres"><div class="col-6 d-block d-md-none order-1">ТБ 2.5</div><div class="col-6 d-block d-md-none order-2">1.80</div></div>
+  </div>
+</div>
+<div class="UserBet">
+  <div class="bet-kind">Ординар</div>
+  <div class="bet-tournament">Англия. Премьер-лига</div>
+  <div class="bet-stake">1 000 ₽</div>
+  <div class="sides"><span>Арсенал</span><span>Челси</span></div>
+  <div class="exspres"><div class="col-6 d-block d-md-none order-1">Х2</div><div class="col-6 d-block d-md-none order-2">3.40</div></div>
+</div>`
+
+func TestSiteProviderConfiguredSelectors(t *testing.T) {
+	srv := serveBets(t, []byte(configuredPage))
+	cfg := config.ScraperConfig{
+		BaseURL: srv.URL,
+		Providers: []config.OutcomeProviderConfig{{
+			Name: "main",
+			Selectors: config.OutcomeSelectors{
+				Stake:   []string{".bet-stake"},
+				BetType: []string{".bet-kind"},
+				League:  []string{".bet-tournament"},
+				Express: config.ExpressSelectors{Event: ".express-event"},
+			},
+		}},
+	}
+	p := NewSiteProvider(cfg.OutcomeProviders()[0], srv.Client(), nopMetrics{})
+
+	t.Run("single skips express card", func(t *testing.T) {
+		o, err := p.FindOutcome(context.Background(), &domain.Forecast{Capper: "Tester", HomeTeam: "Арсенал", AwayTeam: "Челси"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := domain.Outcome{
+			Text:    "Х2",
+			Coef:    decimal.RequireFromString("3.4"),
+			Stake:   decimal.NewFromInt(1000),
+			BetType: domain.BetSingle,
+			League:  "Англия. Премьер-лига",
+		}
+		if o.Text != want.Text || !o.Coef.Equal(want.Coef) || !o.Stake.Equal(want.Stake) || o.BetType != want.BetType || o.League != want.League {
+			t.Errorf("outcome = %+v, want %+v", o, want)
+		}
+	})
+
+	t.Run("express", func(t *testing.T) {
+		f := &domain.Forecast{Capper: "Tester", Legs: []domain.Leg{
+			{HomeTeam: "Реал Мадрид", AwayTeam: "Барселона"},
+			{HomeTeam: "Арсенал", AwayTeam: "Челси"},
+		}}
+		o, err := p.FindOutcome(context.Background(), f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if o.BetType != domain.BetExpress || !o.Stake.Equal(decimal.NewFromInt(500)) {
+			t.Errorf("bet type = %q, stake = %s", o.BetType, o.Stake)
+		}
+		if len(o.Legs) != 2 || o.Legs[0].Text != "ТБ 2.5" || o.Legs[1].Text != "П1" {
+			t.Fatalf("legs = %+v", o.Legs)
+		}
+		// общего кф на странице нет — произведение кф событий
+		if !o.Coef.Equal(decimal.RequireFromString("3.42")) {
+			t.Errorf("coef = %s, want 3.42", o.Coef)
+		}
+	})
+
+	t.Run("express without selectors", func(t *testing.T) {
+		defaults := config.ScraperConfig{BaseURL: srv.URL}
+		def := NewSiteProvider(defaults.OutcomeProviders()[0], srv.Client(), nopMetrics{})
+		f := &domain.Forecast{Capper: "Tester", Legs: []domain.Leg{{HomeTeam: "Арсенал", AwayTeam: "Челси"}, {HomeTeam: "Барселона", AwayTeam: "Реал Мадрид"}}}
+		if _, err := def.FindOutcome(context.Background(), f); !errors.Is(err, ErrExpressUnsupported) || retryable(err) {
+			t.Errorf("err = %v, want non-retryable ErrExpressUnsupported", err)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package prediction
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// stage — пул воркеров с ограниченными очередями. Задачи с одинаковым ключом
+// попадают к одному воркеру и обрабатываются строго по порядку.
+type stage[T any] struct {
+	queues []chan T
+	handle func(T)
+	wg     sync.WaitGroup
+}
+
+func newStage[T any](workers, queueSize int, handle func(T)) *stage[T] {
+	if workers < 1 {
+		workers = 1
+	}
+	s := &stage[T]{
+		queues: make([]chan T, workers),
+		handle: handle,
+	}
+	for i := range s.queues {
+		s.queues[i] = make(chan T, queueSize)
+	}
+	return s
+}
+
+func (s *stage[T]) start() {
+	for _, q := range s.queues {
+		s.wg.Add(1)
+		go func(q chan T) {
+			defer s.wg.Done()
+			for item := range q {
+				s.handle(item)
+			}
+		}(q)
+	}
+}
+
+// submit блокируется, если очередь воркера заполнена
+func (s *stage[T]) submit(key string, item T) {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	s.queues[h.Sum32()%uint32(len(s.queues))] <- item
+}
+
+// stop закрывает очереди и ждёт, пока воркеры доработают оставшееся
+func (s *stage[T]) stop() {
+	for _, q := range s.queues {
+		close(q)
+	}
+	s.wg.Wait()
+}
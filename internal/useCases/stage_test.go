@@ -0,0 +1,92 @@
+package prediction
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type keyedItem struct {
+	key string
+	seq int
+}
+
+// Задачи одного ключа обрабатываются по порядку даже при нескольких воркерах
+func TestStageKeepsOrderPerKey(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen = make(map[string][]int)
+	)
+	s := newStage(4, 8, func(it keyedItem) {
+		mu.Lock()
+		seen[it.key] = append(seen[it.key], it.seq)
+		mu.Unlock()
+	})
+	s.start()
+
+	const keys, perKey = 10, 50
+	for i := 0; i < perKey; i++ {
+		for k := 0; k < keys; k++ {
+			key := fmt.Sprintf("capper-%d", k)
+			s.submit(key, keyedItem{key: key, seq: i})
+		}
+	}
+	s.stop()
+
+	if len(seen) != keys {
+		t.Fatalf("handled %d keys, want %d", len(seen), keys)
+	}
+	for key, seqs := range seen {
+		if len(seqs) != perKey {
+			t.Errorf("%s: handled %d items, want %d", key, len(seqs), perKey)
+			continue
+		}
+		for i, seq := range seqs {
+			if seq != i {
+				t.Errorf("%s: order %v, want ascending", key, seqs)
+				break
+			}
+		}
+	}
+}
+
+// stop дорабатывает всё, что уже в очередях
+func TestStageDrainsOnStop(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		handled int
+	)
+	s := newStage(2, 100, func(int) {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		handled++
+		mu.Unlock()
+	})
+	s.start()
+
+	const n = 60
+	for i := 0; i < n; i++ {
+		s.submit(fmt.Sprint(i%3), i)
+	}
+	s.stop()
+
+	if handled != n {
+		t.Errorf("handled %d items before stop returned, want %d", handled, n)
+	}
+}
+
+func TestStageAtLeastOneWorker(t *testing.T) {
+	done := make(chan int, 1)
+	s := newStage(0, 1, func(i int) { done <- i })
+	s.start()
+	s.submit("any", 7)
+	s.stop()
+
+	if len(s.queues) != 1 {
+		t.Errorf("workers = %d, want 1", len(s.queues))
+	}
+	if got := <-done; got != 7 {
+		t.Errorf("handled %d, want 7", got)
+	}
+}
@@ -0,0 +1,276 @@
+package prediction
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/larriantoniy/tg_pipe_bot/internal/config"
+	"github.com/larriantoniy/tg_pipe_bot/internal/domain"
+	"github.com/larriantoniy/tg_pipe_bot/internal/parse"
+	"github.com/larriantoniy/tg_pipe_bot/internal/ports"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultTemplate воспроизводит прежний формат сообщения;
+// экспресс выводится по событиям, каждое со своим исходом, и общим кф
+const DefaultTemplate = `{{if .Events}}{{if .Sport}}{{esc .Sport}}
+{{end}}Экспресс
+{{range .Events}}
+{{if .League}}{{esc .League}}
+{{end}}🕓 {{esc (kickoff .Kickoff)}}
+{{esc .Teams}}
+🎯 {{esc (default "—" .Outcome.Text)}}{{if .CoefText}} ({{esc .CoefText}}){{end}}
+{{end}}
+📈 Общий кф: {{esc .CoefText}}{{else}}{{if .Sport}}{{esc .Sport}}
+{{end}}{{if .League}}{{esc .League}}
+{{end}}{{if or .Sport .League}}
+{{end}}🕓 {{esc (kickoff .Kickoff)}}
+{{esc .Teams}}
+
+🎯 {{esc (default "—" .Outcome.Text)}}
+📈 Кф: {{esc .CoefText}}{{end}}`
+
+// MessageData — данные шаблона: все поля анонса (время уже в поясе канала),
+// найденная на сайте ставка и готовая строка коэффициента
+type MessageData struct {
+	domain.Forecast
+	Outcome domain.Outcome
+	ChatID  int64
+	// CoefText — кф как в стандартном шаблоне: с сайта, если он там есть,
+	// иначе из анонса («~2» для приблизительного), «?» если нет нигде
+	CoefText string
+	// CapperURL — страница каппера на сайте
+	CapperURL string
+	// Events — события экспресса с их исходами; у ординара пусто
+	Events []*EventData
+}
+
+// EventData — событие экспресса: поля события (время в поясе канала) и его исход на сайте
+type EventData struct {
+	domain.Leg
+	Outcome domain.LegOutcome
+	// CoefText — кф события или пусто, если сайт его не показал
+	CoefText string
+}
+
+// Templates — скомпилированные шаблоны: свой для канала или общий
+type Templates struct {
+	def     channelTemplate
+	byChat  map[int64]channelTemplate
+	siteURL string
+	parser  ports.TextParser
+}
+
+// channelTemplate — шаблон, разметка его результата ("", markdown или html)
+// и нужно ли прикладывать фото из исходного анонса
+type channelTemplate struct {
+	tpl         *template.Template
+	mode        string
+	attachPhoto bool
+}
+
+// templateFuncs — помощники, доступные в шаблонах
+var templateFuncs = template.FuncMap{
+	// kickoff — «02 января 15:04»
+	"kickoff": func(t time.Time) string { return parse.FormatKickoff(t, nil) },
+	// date — время по layout из пакета time, например "02.01 15:04"
+	"date": func(layout string, t time.Time) string { return t.Format(layout) },
+	// round — кф с округлением до places знаков без хвостовых нулей, fixed — ровно places знаков
+	"round": func(places int32, d decimal.Decimal) string { return d.Round(places).String() },
+	"fixed": func(places int32, d decimal.Decimal) string { return d.StringFixed(places) },
+	// default — def, если s пустая после обрезки пробелов; esc — экранирование под parse_mode шаблона
+	"default": func(def, s string) string {
+		if s = strings.TrimSpace(s); s == "" {
+			return def
+		}
+		return s
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+}
+
+// markdownSpecial — символы, которые MarkdownV2 требует экранировать
+var markdownSpecial = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+	"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// escaper — функция esc для шаблона: экранирует значение под его разметку
+func escaper(mode string) func(string) string {
+	switch mode {
+	case "markdown":
+		return markdownSpecial.Replace
+	case "html":
+		return html.EscapeString
+	default:
+		return func(s string) string { return s }
+	}
+}
+
+// NewTemplates компилирует шаблоны и пробует выполнить каждый на образце анонса
+// и разобрать разметку, чтобы ошибки всплыли при старте, а не при отправке.
+// siteURL — сайт капперов для .CapperURL.
+func NewTemplates(cfg config.TemplatesConfig, siteURL string, parser ports.TextParser) (*Templates, error) {
+	def := cfg.Default
+	if strings.TrimSpace(def) == "" {
+		def = DefaultTemplate
+	}
+	t := &Templates{
+		byChat:  make(map[int64]channelTemplate, len(cfg.Channels)),
+		siteURL: strings.TrimRight(siteURL, "/") + "/",
+		parser:  parser,
+	}
+
+	var err error
+	if t.def, err = t.compile("default", def, cfg.ParseMode); err != nil {
+		return nil, err
+	}
+	t.def.attachPhoto = cfg.AttachPhoto
+	for chatID, ch := range cfg.Channels {
+		ct, err := t.compile(fmt.Sprintf("channels[%d]", chatID), ch.Text, ch.ParseMode)
+		if err != nil {
+			return nil, err
+		}
+		ct.attachPhoto = ch.AttachPhoto
+		t.byChat[chatID] = ct
+	}
+	return t, nil
+}
+
+func (t *Templates) compile(name, text, mode string) (channelTemplate, error) {
+	tpl, err := template.New(name).
+		Funcs(templateFuncs).
+		Funcs(template.FuncMap{"esc": escaper(mode)}).
+		Option("missingkey=error").
+		Parse(text)
+	if err != nil {
+		return channelTemplate{}, fmt.Errorf("templates.%s: %w", name, err)
+	}
+	ct := channelTemplate{tpl: tpl, mode: mode}
+	for _, sample := range []*MessageData{sampleMessage(), sampleExpress()} {
+		if _, err := t.render(ct, sample); err != nil {
+			return channelTemplate{}, fmt.Errorf("templates.%s: %w", name, err)
+		}
+	}
+	return ct, nil
+}
+
+func (t *Templates) render(ct channelTemplate, data *MessageData) (domain.FormattedText, error) {
+	var b strings.Builder
+	if err := ct.tpl.Execute(&b, data); err != nil {
+		return domain.FormattedText{}, err
+	}
+	return t.parser.ParseText(b.String(), ct.mode)
+}
+
+// sampleMessage — образец с заполненными полями для проверки шаблонов
+func sampleMessage() *MessageData {
+	kickoff := time.Date(2025, time.January, 2, 15, 4, 0, 0, time.UTC)
+	return &MessageData{
+		Forecast: domain.Forecast{
+			Kind:     domain.KindNewForecast,
+			Capper:   "Capper",
+			Sport:    "Футбол",
+			League:   "Лига",
+			HomeTeam: "Хозяева",
+			AwayTeam: "Гости",
+			Kickoff:  kickoff,
+			Coef:     decimal.RequireFromString("1.85"),
+			Stake:    decimal.NewFromInt(100),
+			Legs: []domain.Leg{
+				{League: "Лига", HomeTeam: "Хозяева", AwayTeam: "Гости", Kickoff: kickoff},
+			},
+		},
+		Outcome: domain.Outcome{
+			Text:    "П1",
+			Coef:    decimal.RequireFromString("1.9"),
+			Stake:   decimal.NewFromInt(100),
+			BetType: domain.BetSingle,
+			League:  "Лига",
+		},
+		CoefText:  "1.9",
+		CapperURL: "https://example.com/Capper",
+	}
+}
+
+// sampleExpress — образец экспресса из двух событий
+func sampleExpress() *MessageData {
+	m := sampleMessage()
+	second := domain.Leg{League: "Лига 2", HomeTeam: "Хозяева 2", AwayTeam: "Гости 2", Kickoff: m.Kickoff.Add(2 * time.Hour)}
+	m.Kind = domain.KindExpress
+	m.Legs = append(m.Legs, second)
+	m.Outcome = domain.Outcome{
+		Text:    "П1; ТБ 2.5",
+		Coef:    decimal.RequireFromString("3.42"),
+		BetType: domain.BetExpress,
+		Legs: []domain.LegOutcome{
+			{Text: "П1", Coef: decimal.RequireFromString("1.8")},
+			{Text: "ТБ 2.5", Coef: decimal.RequireFromString("1.9")},
+		},
+	}
+	m.CoefText = "3.42"
+	m.Events = events(m.Legs, m.Outcome.Legs)
+	return m
+}
+
+// events сводит события экспресса с их исходами; исходов может не быть
+// (например, прогноз поднят из хранилища без них) — тогда поля пустые
+func events(legs []domain.Leg, outcomes []domain.LegOutcome) []*EventData {
+	out := make([]*EventData, len(legs))
+	for i, leg := range legs {
+		ev := &EventData{Leg: leg}
+		if i < len(outcomes) {
+			ev.Outcome = outcomes[i]
+			if !ev.Outcome.Coef.IsZero() {
+				ev.CoefText = ev.Outcome.Coef.String()
+			}
+		}
+		out[i] = ev
+	}
+	return out
+}
+
+// Execute рендерит сообщение для канала chatID; время переводится в loc.
+// Если шаблон просит фото, а у анонса оно есть, текст становится подписью к нему.
+func (t *Templates) Execute(f *domain.Forecast, o domain.Outcome, chatID int64, loc *time.Location) (domain.OutgoingMessage, error) {
+	ct, ok := t.byChat[chatID]
+	if !ok {
+		ct = t.def
+	}
+
+	data := &MessageData{
+		Forecast:  *f,
+		Outcome:   o,
+		ChatID:    chatID,
+		CoefText:  formatCoef(f, o),
+		CapperURL: t.siteURL + url.PathEscape(f.Capper),
+	}
+	if data.League == "" {
+		data.League = o.League
+	}
+	data.Kickoff = f.Kickoff.In(loc)
+	data.Legs = make([]domain.Leg, len(f.Legs))
+	for i, leg := range f.Legs {
+		leg.Kickoff = leg.Kickoff.In(loc)
+		data.Legs[i] = leg
+	}
+	if len(f.Legs) > 1 {
+		data.Events = events(data.Legs, o.Legs)
+	}
+
+	text, err := t.render(ct, data)
+	if err != nil {
+		return domain.OutgoingMessage{}, fmt.Errorf("шаблон канала %d: %w", chatID, err)
+	}
+	msg := domain.OutgoingMessage{Text: text}
+	if ct.attachPhoto {
+		msg.PhotoFile = f.PhotoFile
+	}
+	return msg, nil
+}